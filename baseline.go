@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+	"github.com/fatih/color"
+)
+
+func runBaseline(arguments []string) {
+	var args scanArgs
+	var jsonOutput bool
+
+	flags := newFlagSet("baseline", "[options] <target>", "Run only the baseline phase of a scan against a target and show what the scanner learned: every random\nhostname that was sent for each variant and path with its status, title, body length, headers and\nfingerprint, the clusters of identical responses, and the pairwise body similarity. Candidates whose\nbody is more similar than -similarity to any baseline body are not reported. Bare hosts and IPs are\nexpanded with -ports.")
+	flags.BoolVar(&jsonOutput, "json", false, "Print everything, including request lines and response bodies, as JSON")
+	flags.StringVar(&args.outputFile, "o", "", "Path to save the JSON dump to")
+	flags.StringVar(&args.ports, "ports", "80,443", "Ports to expand a bare IP or hostname into")
+	flags.Float64Var(&args.similarity, "similarity", scanner.DefaultSimilarityThreshold, "Body similarity percentage to the baseline above which a response is not reported as a vhost")
+	args.registerProbeFlags(flags)
+	args.registerRequestFlags(flags)
+	args.registerConfigFlags(flags)
+	parseFlags(flags, arguments, &args, "o")
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	ports, err := scanner.ParsePorts(args.ports)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	targetPaths := scanner.NewTargetPaths()
+	targets, err := targetPaths.Expand(flags.Arg(0), ports)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	options, err := args.scannerOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	options.TargetPaths = targetPaths
+
+	scannerInstance, err := scanner.NewScanner(targets, nil, options)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer scannerInstance.Close()
+
+	ctx, stop := interruptContext(&printer{}, args.drainTimeout)
+	defer stop()
+
+	learned := []*scanner.TargetBaseline{}
+	for _, target := range targets {
+		baseline, err := scannerInstance.LearnBaselines(ctx, target)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			learned = append(learned, &scanner.TargetBaseline{Target: target, Error: err.Error()})
+			if !jsonOutput {
+				fmt.Printf("%s - %s\n", color.YellowString(target), color.RedString(err.Error()))
+			}
+			continue
+		}
+
+		learned = append(learned, baseline)
+		if !jsonOutput {
+			printBaseline(baseline)
+		}
+	}
+
+	if args.outputFile != "" {
+		content, err := json.MarshalIndent(learned, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(args.outputFile, append(content, '\n'), 0644); err != nil {
+			fmt.Printf("Error writing baseline: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(learned)
+	}
+}
+
+func printBaseline(learned *scanner.TargetBaseline) {
+	fmt.Println(color.YellowString(learned.Target))
+	if learned.HTTP3Target != "" {
+		fmt.Printf("  HTTP/3: %s\n", learned.HTTP3Target)
+	}
+
+	for _, probe := range learned.Probes {
+		fmt.Printf("\n  [Variant: %s] [Path: %s]\n", color.BlueString(probe.Variant), color.BlueString(probe.Path))
+
+		fmt.Println("    Probes:")
+		for _, sample := range probe.Samples {
+			if sample.Error != "" {
+				fmt.Printf("      %s %s\n", color.CyanString(sample.VHost), color.RedString(sample.Error))
+				continue
+			}
+
+			fmt.Printf("      %s [%d] [%s] [%d bytes] [%s] [%dms]\n",
+				color.CyanString(sample.VHost),
+				sample.StatusCode,
+				color.WhiteString(sample.Title),
+				sample.BodyLength,
+				color.MagentaString(sample.Fingerprint),
+				sample.ResponseTime,
+			)
+			for _, header := range sample.Headers {
+				fmt.Printf("        %s: %s\n", header.Name, header.Value)
+			}
+		}
+
+		if probe.StatusCodes == nil {
+			fmt.Printf("    %s\n", color.RedString("No baseline response, candidates on this probe are never reported"))
+			continue
+		}
+
+		fmt.Println("    Clusters:")
+		for i, cluster := range probe.Clusters {
+			fmt.Printf("      %d. [%d] [%s] [%d bytes] [%s] %s\n",
+				i+1,
+				cluster.StatusCode,
+				color.WhiteString(cluster.Title),
+				cluster.BodyLength,
+				color.MagentaString(cluster.Fingerprint),
+				strings.Join(cluster.VHosts, ", "),
+			)
+		}
+
+		if len(probe.Similarity) > 0 {
+			fmt.Println("    Similarity:")
+			for _, score := range probe.Similarity {
+				fmt.Printf("      %s <-> %s %s\n", score.First, score.Second, similarityColor(score.Similarity, learned.SimilarityThreshold))
+			}
+		}
+
+		var statusCodes, titles []string
+		for _, statusCode := range probe.StatusCodes {
+			statusCodes = append(statusCodes, fmt.Sprint(statusCode))
+		}
+		for _, title := range probe.Titles {
+			titles = append(titles, fmt.Sprintf("%q", title))
+		}
+
+		fmt.Printf("    Learned: status codes %s; titles %s\n", strings.Join(statusCodes, ", "), strings.Join(titles, ", "))
+		fmt.Printf("    Candidates with a learned status code and title are ignored when their body is more than %.0f%% similar to a probe body\n",
+			learned.SimilarityThreshold)
+	}
+}
+
+func similarityColor(similarity float64, threshold float64) string {
+	if similarity > threshold {
+		return color.GreenString("%.1f%%", similarity)
+	}
+	return color.RedString("%.1f%%", similarity)
+}
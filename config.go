@@ -0,0 +1,280 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var configFlags = map[string]string{
+	"targets":           "u",
+	"targets_file":      "l",
+	"wordlist":          "w",
+	"ports":             "ports",
+	"import":            "import",
+	"import_format":     "import-format",
+	"threads":           "t",
+	"concurrent_vhosts": "c",
+	"verbose":           "verbose",
+	"silent":            "silent",
+	"no_progress":       "no-progress",
+	"internal":          "internal",
+	"outputs":           "o",
+	"append":            "append",
+	"sync_interval":     "sync-interval",
+	"rotate_size":       "rotate-size",
+	"minimal":           "minimal",
+	"http3":             "http3",
+	"raw":               "raw",
+	"override":          "override",
+	"paths":             "paths",
+	"ip_mode":           "ip-mode",
+	"matrix":            "matrix",
+	"origin":            "origin",
+	"state":             "state",
+	"resume":            "resume",
+	"drain_timeout":     "drain-timeout",
+	"similarity":        "similarity",
+	"rules":             "rules",
+	"record":            "record",
+	"replay":            "replay",
+	"har":               "har",
+	"har_body_limit":    "har-body-limit",
+	"headers":           "H",
+	"user_agent":        "ua",
+	"proxy":             "proxy",
+	"rate_limit":        "rate",
+}
+
+var matcherConfigFlags = map[string]string{
+	"status": "c",
+	"size":   "s",
+	"words":  "w",
+	"lines":  "l",
+	"regex":  "r",
+	"time":   "t",
+	"mode":   "mode",
+}
+
+var builtinProfiles = map[string]map[string]any{
+	"stealth": {
+		"threads":           1,
+		"concurrent_vhosts": 2,
+		"rate_limit":        5,
+		"user_agent":        "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+		"filter":            map[string]any{"status": "429"},
+	},
+	"fast": {
+		"threads":           10,
+		"concurrent_vhosts": 50,
+		"minimal":           true,
+		"drain_timeout":     "1s",
+	},
+	"bugbounty": {
+		"threads":           5,
+		"concurrent_vhosts": 20,
+		"rate_limit":        100,
+		"override":          "all",
+		"filter":            map[string]any{"status": "429"},
+	},
+}
+
+type config struct {
+	options  map[string]any
+	profiles map[string]map[string]any
+}
+
+func loadConfig(path string) (*config, error) {
+	loaded := &config{profiles: make(map[string]map[string]any)}
+	if path == "" {
+		return loaded, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		err = yaml.Unmarshal(content, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if profiles, ok := values["profiles"]; ok {
+		profileMap, ok := profiles.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: profiles must be a map of profile names to options", path)
+		}
+
+		for name, profile := range profileMap {
+			options, ok := profile.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: profile %s must be a map of options", path, name)
+			}
+			loaded.profiles[name] = options
+		}
+		delete(values, "profiles")
+	}
+	loaded.options = values
+
+	return loaded, nil
+}
+
+func (c *config) profileNames() []string {
+	names := slices.Collect(maps.Keys(builtinProfiles))
+	for name := range c.profiles {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (c *config) resolve(profile string) (map[string][]string, error) {
+	resolved := make(map[string][]string)
+
+	layers := []map[string]any{c.options}
+	if profile != "" {
+		builtin, isBuiltin := builtinProfiles[profile]
+		custom, isCustom := c.profiles[profile]
+		if !isBuiltin && !isCustom {
+			return nil, fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(c.profileNames(), ", "))
+		}
+		layers = append(layers, builtin, custom)
+	}
+
+	for _, layer := range layers {
+		values, err := configFlagValues(layer)
+		if err != nil {
+			return nil, err
+		}
+		if headers, ok := values["H"]; ok {
+			values["H"] = mergeHeaders(resolved["H"], headers)
+		}
+		maps.Copy(resolved, values)
+	}
+
+	return resolved, nil
+}
+
+func configFlagValues(options map[string]any) (map[string][]string, error) {
+	values := make(map[string][]string)
+
+	for key, value := range options {
+		if key == "match" || key == "filter" {
+			matcher, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("config option %s must be a map", key)
+			}
+
+			for matcherKey, matcherValue := range matcher {
+				suffix, ok := matcherConfigFlags[matcherKey]
+				if !ok {
+					return nil, fmt.Errorf("unknown config option %s.%s", key, matcherKey)
+				}
+				values[key[:1]+suffix] = []string{configValue(matcherValue)}
+			}
+			continue
+		}
+
+		name, ok := configFlags[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown config option %s", key)
+		}
+
+		if name == "H" {
+			switch headers := value.(type) {
+			case []any:
+				for _, item := range headers {
+					values[name] = append(values[name], configValue(item))
+				}
+				continue
+			case map[string]any:
+				for _, header := range slices.Sorted(maps.Keys(headers)) {
+					values[name] = append(values[name], header+": "+configValue(headers[header]))
+				}
+				continue
+			}
+		}
+
+		if _, ok := value.(map[string]any); ok {
+			return nil, fmt.Errorf("config option %s must be a value or a list, not a map", key)
+		}
+		values[name] = []string{configValue(value)}
+	}
+
+	return values, nil
+}
+
+func configValue(value any) string {
+	switch value := value.(type) {
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, configValue(item))
+		}
+		return strings.Join(items, ",")
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	}
+	return fmt.Sprint(value)
+}
+
+func mergeHeaders(base []string, headers []string) []string {
+	merged := slices.Clone(base)
+	for _, header := range headers {
+		name, _, _ := strings.Cut(header, ":")
+		merged = slices.DeleteFunc(merged, func(existing string) bool {
+			existingName, _, _ := strings.Cut(existing, ":")
+			return strings.EqualFold(strings.TrimSpace(existingName), strings.TrimSpace(name))
+		})
+	}
+	return append(merged, headers...)
+}
+
+func applyConfig(flags *flag.FlagSet, path string, profile string, local ...string) error {
+	loaded, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := loaded.resolve(profile)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for name, values := range resolved {
+		if explicit[name] || flags.Lookup(name) == nil || slices.Contains(local, name) {
+			continue
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("invalid config value for -%s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
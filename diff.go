@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+	"github.com/fatih/color"
+)
+
+func runDiff(arguments []string) {
+	flags := newFlagSet("diff", "[options] <old> <new>", "Compare two json or jsonl output files and report new, vanished and changed vhosts.")
+	jsonOutput := flags.Bool("json", false, "Print the diff as JSON")
+	outputFile := flags.String("o", "", "Path to save the diff as JSON")
+	flags.Parse(arguments)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	oldHits, err := scanner.LoadHitRecords(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	newHits, err := scanner.LoadHitRecords(flags.Arg(1))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	diff := scanner.DiffHitRecords(oldHits, newHits)
+
+	if *outputFile != "" {
+		content, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(*outputFile, append(content, '\n'), 0644); err != nil {
+			fmt.Printf("Error writing diff: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(diff)
+		return
+	}
+
+	printDiff(diff)
+}
+
+func printDiff(diff scanner.ScanDiff) {
+	for _, hit := range diff.Added {
+		fmt.Printf("%s %s - %s [%d] [%s]%s\n",
+			color.GreenString("+"),
+			color.YellowString(hit.Target),
+			color.CyanString(hit.VHost),
+			hit.StatusCode,
+			color.WhiteString(hit.Title),
+			diffQualifier(hit.Path, hit.Variant),
+		)
+	}
+
+	for _, hit := range diff.Removed {
+		fmt.Printf("%s %s - %s [%d] [%s]%s\n",
+			color.RedString("-"),
+			color.YellowString(hit.Target),
+			color.CyanString(hit.VHost),
+			hit.StatusCode,
+			color.WhiteString(hit.Title),
+			diffQualifier(hit.Path, hit.Variant),
+		)
+	}
+
+	for _, change := range diff.Changed {
+		fmt.Printf("%s %s - %s%s\n",
+			color.YellowString("~"),
+			color.YellowString(change.Target),
+			color.CyanString(change.VHost),
+			diffQualifier(change.Path, change.Variant),
+		)
+		for _, field := range change.Fields {
+			fmt.Printf("    %s: %s -> %s\n", field.Field, formatDiffValue(field.Old), formatDiffValue(field.New))
+		}
+	}
+
+	if diff.Empty() {
+		fmt.Println("No changes")
+		return
+	}
+
+	fmt.Printf("\n%d new, %d vanished, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+}
+
+func diffQualifier(path string, variant string) string {
+	qualifier := ""
+	if path != "" && path != "/" {
+		qualifier += fmt.Sprintf(" [Path: %s]", color.BlueString(path))
+	}
+	if variant != "" && variant != scanner.VariantHost {
+		qualifier += fmt.Sprintf(" [Variant: %s]", color.BlueString(variant))
+	}
+	return qualifier
+}
+
+func formatDiffValue(value any) string {
+	if text, ok := value.(string); ok {
+		return fmt.Sprintf("%q", text)
+	}
+	return fmt.Sprint(value)
+}
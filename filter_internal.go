@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+)
+
+func runFilterInternal(arguments []string) {
+	var args scanArgs
+
+	flags := newFlagSet("filter-internal", "[options] -w <wordlist>", "Check every hostname of the wordlist and print the ones that are not directly accessible (no public DNS\nrecord, private addresses, or no HTTP response), one per line and in wordlist order. These are the\nhostnames scan -internal keeps.")
+	flags.StringVar(&args.wordlist, "w", "", "Path to file containing hostnames (one per line), or - for stdin")
+	flags.StringVar(&args.outputFile, "o", "", "Path to save the internal hostnames to instead of printing them")
+	args.registerRequestFlags(flags)
+	args.registerDisplayFlags(flags)
+	args.registerConfigFlags(flags)
+	parseFlags(flags, arguments, &args, "o")
+
+	if args.wordlist == "" {
+		fmt.Println("Error: wordlist parameter is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	options, err := args.scannerOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cli := args.newPrinter()
+	if args.outputFile == "" {
+		cli.silent, cli.noProgress = true, true
+	}
+
+	var wordlist *scanner.StreamList
+	if args.wordlist == "-" {
+		wordlist = scanner.NewStreamListFromReader(os.Stdin, nil, cli.Warn)
+	} else {
+		hostnames, err := readLines(args.wordlist)
+		if err != nil {
+			fmt.Printf("Error reading wordlist file: %v\n", err)
+			os.Exit(1)
+		}
+		wordlist = scanner.NewStaticList(hostnames)
+	}
+
+	options.Hooks = cli.Hooks()
+	scannerInstance, err := scanner.NewStreamingScanner(scanner.NewStaticList(nil), wordlist, options)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer scannerInstance.Close()
+	cli.scanner = scannerInstance
+
+	ctx, stop := interruptContext(cli, args.drainTimeout)
+	defer stop()
+
+	originalCount, internalCount, err := scannerInstance.RemoveNonInternalHosts(ctx)
+	cli.Finish()
+	if err != nil {
+		fmt.Println("Error: filtering interrupted")
+		os.Exit(1)
+	}
+
+	hostnames := scannerInstance.Wordlist.All()
+	if args.outputFile == "" {
+		for _, hostname := range hostnames {
+			fmt.Println(hostname)
+		}
+		return
+	}
+
+	content := ""
+	if len(hostnames) > 0 {
+		content = strings.Join(hostnames, "\n") + "\n"
+	}
+	if err := os.WriteFile(args.outputFile, []byte(content), 0644); err != nil {
+		fmt.Printf("Error writing internal hosts: %v\n", err)
+		os.Exit(1)
+	}
+
+	cli.Info("Filtered wordlist from %d to %d internal hosts, saved to %s", originalCount, internalCount, args.outputFile)
+}
@@ -3,17 +3,24 @@ module github.com/bebiksior/go-vhosts
 go 1.24.0
 
 require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/adrg/strutil v0.3.1
 	github.com/fatih/color v1.18.0
+	github.com/quic-go/quic-go v0.59.1
 	github.com/schollz/progressbar/v3 v3.18.0
 	github.com/sergi/go-diff v1.3.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/adrg/strutil v0.3.1 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
+	github.com/quic-go/qpack v0.6.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/term v0.28.0 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/term v0.34.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
 )
@@ -5,11 +5,28 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bebiksior/go-vhosts/pkg/scanner"
+	"github.com/fatih/color"
 )
 
-var args struct {
+type command struct {
+	name    string
+	summary string
+	run     func(arguments []string)
+}
+
+var commands = []command{
+	{"scan", "Scan targets for virtual hosts (default when the first argument is an option)", runScan},
+	{"filter-internal", "Print the hostnames of a wordlist that are not directly accessible", runFilterInternal},
+	{"verify", "Re-check the hits of an output file", runVerify},
+	{"diff", "Compare two output files", runDiff},
+	{"baseline", "Show what the scanner learns about a target before scanning it", runBaseline},
+	{"serve", "Run scans through an HTTP JSON API", runServe},
+}
+
+type scanArgs struct {
 	targets          string
 	targetsList      string
 	wordlist         string
@@ -19,70 +36,247 @@ var args struct {
 	internal         bool
 	outputFile       string
 	minimal          bool
+	http3            bool
+	raw              string
+	override         string
+	paths            string
+	ports            string
+	importFile       string
+	importFormat     string
+	ipMode           bool
+	matrixFile       string
+	origin           bool
+	stateFile        string
+	resume           string
+	drainTimeout     time.Duration
+	similarity       float64
+	recordFile       string
+	replayFile       string
+	harFile          string
+	harBodyLimit     int
+	syncInterval     time.Duration
+	rotateSize       int64
+	appendOutput     bool
+	match            scanner.MatcherConfig
+	filter           scanner.MatcherConfig
+	rulesFile        string
+	headers          headerFlags
+	userAgent        string
+	proxy            string
+	rateLimit        float64
+	silent           bool
+	noProgress       bool
+	configFile       string
+	profile          string
+}
+
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
 }
 
 func main() {
-	flag.StringVar(&args.targets, "u", "", "Comma-separated list of targets to scan")
-	flag.StringVar(&args.targetsList, "l", "", "Path to file containing targets (one per line)")
-	flag.StringVar(&args.wordlist, "w", "", "Path to file containing vhosts (one per line)")
-	flag.IntVar(&args.threads, "t", 3, "Number of concurrent target scans")
-	flag.IntVar(&args.concurrentVHosts, "c", 5, "Number of concurrent vhost checks per target")
-	flag.BoolVar(&args.verbose, "verbose", false, "Enable verbose output")
-	flag.BoolVar(&args.internal, "internal", false, "Filter wordlist to only include internal hosts")
-	flag.StringVar(&args.outputFile, "o", "", "Path to save JSON results (one result per line)")
-	flag.BoolVar(&args.minimal, "minimal", false, "Skip similarity comparison for faster scanning with less CPU usage")
-	flag.Parse()
-
-	if args.targets == "" && args.targetsList == "" {
-		fmt.Println("Error: either -u or -l parameter is required")
-		flag.Usage()
+	if len(os.Args) < 2 {
+		printUsage()
 		os.Exit(1)
 	}
 
-	var targets []string
-	if args.targets != "" {
-		targets = strings.Split(args.targets, ",")
-	} else {
-		content, err := os.ReadFile(args.targetsList)
+	name, arguments := os.Args[1], os.Args[2:]
+	switch {
+	case name == "-h" || name == "-help" || name == "--help":
+		printUsage()
+		return
+	case name == "help":
+		if len(arguments) == 0 {
+			printUsage()
+			return
+		}
+		name, arguments = arguments[0], []string{"-h"}
+	case strings.HasPrefix(name, "-"):
+		name, arguments = "scan", os.Args[1:]
+	}
+
+	for _, command := range commands {
+		if command.name == name {
+			command.run(arguments)
+			return
+		}
+	}
+
+	fmt.Printf("Error: unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(1)
+}
+
+func printUsage() {
+	fmt.Printf("Usage: go-vhosts <command> [options]\n\nCommands:\n")
+	for _, command := range commands {
+		fmt.Printf("  %-16s %s\n", command.name, command.summary)
+	}
+	fmt.Printf("\nRun \"go-vhosts <command> -h\" for the options of a command.\n")
+}
+
+func newFlagSet(name string, usage string, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go-vhosts %s %s\n\n%s\n\n", name, usage, description)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, arguments []string, args *scanArgs, local ...string) {
+	flags.Parse(arguments)
+
+	if err := applyConfig(flags, args.configFile, args.profile, local...); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args.silent {
+		color.NoColor = true
+	}
+}
+
+func (a *scanArgs) registerConfigFlags(flags *flag.FlagSet) {
+	flags.StringVar(&a.configFile, "config", "", "Path to a YAML or TOML config file")
+	flags.StringVar(&a.profile, "profile", "", "Named profile to apply (built-in: stealth, fast, bugbounty, or one defined in -config)")
+}
+
+func (a *scanArgs) registerDisplayFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&a.verbose, "verbose", false, "Enable verbose output")
+	flags.BoolVar(&a.silent, "silent", false, "Only print results, without colors, progress bar or status messages")
+	flags.BoolVar(&a.noProgress, "no-progress", false, "Disable the progress bar")
+}
+
+func (a *scanArgs) registerRequestFlags(flags *flag.FlagSet) {
+	flags.IntVar(&a.concurrentVHosts, "c", 5, "Number of concurrent vhost checks per target")
+	flags.Var(&a.headers, "H", "Custom header in format \"Name: Value\" sent with every request (can be used multiple times)")
+	flags.StringVar(&a.userAgent, "ua", scanner.DefaultUserAgent, "User-Agent string")
+	flags.StringVar(&a.proxy, "proxy", "", "Proxy URL for all requests (http, https, socks5), e.g. http://127.0.0.1:8080")
+	flags.Float64Var(&a.rateLimit, "rate", 0, "Maximum number of requests per second across all targets (0 means unlimited)")
+	flags.DurationVar(&a.drainTimeout, "drain-timeout", 5*time.Second, "How long to wait for in-flight requests after Ctrl-C before aborting them")
+}
+
+func (a *scanArgs) registerProbeFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&a.http3, "http3", false, "Probe vhosts over HTTP/3 when the target advertises it via Alt-Svc")
+	flags.StringVar(&a.raw, "raw", "", "Use the raw HTTP/1.1 requester with comma-separated Host variants, or \"all\" (host, absolute-uri, duplicate-host, x-forwarded-host, x-host, trailing-dot, port-suffix)")
+	flags.StringVar(&a.override, "override", "", "Keep the target Host and also place candidates in comma-separated override headers, or \"all\" (x-forwarded-host, x-original-host, forwarded, x-rewrite-url)")
+	flags.StringVar(&a.paths, "paths", "", "Comma-separated list of extra paths to probe for each candidate (e.g. /robots.txt,/api/health)")
+}
+
+func (a *scanArgs) registerDetectionFlags(flags *flag.FlagSet) {
+	flags.IntVar(&a.threads, "t", 3, "Number of concurrent target scans")
+	flags.BoolVar(&a.minimal, "minimal", false, "Skip similarity comparison for faster scanning with less CPU usage")
+	flags.Float64Var(&a.similarity, "similarity", scanner.DefaultSimilarityThreshold, "Body similarity percentage to the baseline above which a response is not reported as a vhost")
+	flags.StringVar(&a.rulesFile, "rules", "", "Path to a file of hit/ignore detection rules, optionally scoped per target")
+	flags.StringVar(&a.match.StatusCodes, "mc", "all", "Only report vhosts with these status codes, comma-separated with ranges (e.g. 200,301-302) or \"all\"")
+	flags.StringVar(&a.match.Sizes, "ms", "", "Only report vhosts with these response sizes (e.g. 1337,2000-3000)")
+	flags.StringVar(&a.match.Words, "mw", "", "Only report vhosts with these response word counts")
+	flags.StringVar(&a.match.Lines, "ml", "", "Only report vhosts with these response line counts")
+	flags.StringVar(&a.match.Regexp, "mr", "", "Only report vhosts whose response headers or body match this regular expression")
+	flags.StringVar(&a.match.Time, "mt", "", "Only report vhosts whose response time matches, in milliseconds (e.g. >100 or <500)")
+	flags.StringVar(&a.match.Mode, "mmode", scanner.MatchModeOr, "How to combine matchers (or, and)")
+	flags.StringVar(&a.filter.StatusCodes, "fc", "", "Do not report vhosts with these status codes, comma-separated with ranges (e.g. 404,500-599)")
+	flags.StringVar(&a.filter.Sizes, "fs", "", "Do not report vhosts with these response sizes (e.g. 1337)")
+	flags.StringVar(&a.filter.Words, "fw", "", "Do not report vhosts with these response word counts")
+	flags.StringVar(&a.filter.Lines, "fl", "", "Do not report vhosts with these response line counts")
+	flags.StringVar(&a.filter.Regexp, "fr", "", "Do not report vhosts whose response headers or body match this regular expression")
+	flags.StringVar(&a.filter.Time, "ft", "", "Do not report vhosts whose response time matches, in milliseconds (e.g. >100 or <500)")
+	flags.StringVar(&a.filter.Mode, "fmode", scanner.MatchModeOr, "How to combine filters (or, and)")
+}
+
+func (a *scanArgs) scannerOptions() (scanner.ScannerOptions, error) {
+	var headers []scanner.HeaderField
+	for _, value := range a.headers {
+		header, err := scanner.ParseHeader(value)
 		if err != nil {
-			fmt.Printf("Error reading targets file: %v\n", err)
-			os.Exit(1)
+			return scanner.ScannerOptions{}, err
 		}
-		targets = strings.Split(strings.TrimSpace(string(content)), "\n")
+		headers = append(headers, header)
 	}
 
-	var wordlist []string
+	matcher, err := scanner.NewResponseMatcher(a.match)
+	if err != nil {
+		return scanner.ScannerOptions{}, fmt.Errorf("invalid matcher: %w", err)
+	}
+
+	filter, err := scanner.NewResponseMatcher(a.filter)
+	if err != nil {
+		return scanner.ScannerOptions{}, fmt.Errorf("invalid filter: %w", err)
+	}
 
-	if args.wordlist != "" {
-		content, err := os.ReadFile(args.wordlist)
+	var rules *scanner.RuleSet
+	if a.rulesFile != "" {
+		rules, err = scanner.LoadRuleSet(a.rulesFile)
 		if err != nil {
-			fmt.Printf("Error reading wordlist file: %v\n", err)
-			os.Exit(1)
+			return scanner.ScannerOptions{}, err
 		}
-		wordlist = strings.Split(strings.TrimSpace(string(content)), "\n")
-	} else {
-		fmt.Println("Error: wordlist parameter is required")
-		flag.Usage()
-		os.Exit(1)
 	}
 
-	scannerInstance := scanner.NewScanner(
-		targets,
-		wordlist,
-		scanner.ScannerOptions{
-			Threads:          args.threads,
-			ConcurrentVHosts: args.concurrentVHosts,
-			Verbose:          args.verbose,
-			Internal:         args.internal,
-			OutputFile:       args.outputFile,
-			Minimal:          args.minimal,
-		},
-	)
-	defer scannerInstance.Close()
+	rawVariants, err := scanner.ParseRawVariants(a.raw)
+	if err != nil {
+		return scanner.ScannerOptions{}, err
+	}
+
+	overrideModes, err := scanner.ParseOverrideModes(a.override)
+	if err != nil {
+		return scanner.ScannerOptions{}, err
+	}
+
+	var paths []string
+	if a.paths != "" {
+		paths = strings.Split(a.paths, ",")
+	}
+
+	return scanner.ScannerOptions{
+		Threads:             a.threads,
+		ConcurrentVHosts:    a.concurrentVHosts,
+		Verbose:             a.verbose,
+		Internal:            a.internal,
+		Minimal:             a.minimal,
+		HTTP3:               a.http3,
+		RawVariants:         rawVariants,
+		OverrideModes:       overrideModes,
+		Paths:               paths,
+		DrainTimeout:        a.drainTimeout,
+		SimilarityThreshold: a.similarity,
+		Matcher:             matcher,
+		Filter:              filter,
+		Rules:               rules,
+		Headers:             headers,
+		UserAgent:           a.userAgent,
+		Proxy:               a.proxy,
+		RateLimit:           a.rateLimit,
+	}, nil
+}
+
+func (a *scanArgs) newPrinter() *printer {
+	return &printer{verbose: a.verbose, silent: a.silent, noProgress: a.noProgress || a.silent}
+}
 
-	if args.internal {
-		scannerInstance.RemoveNonInternalHosts()
+func splitTargets(value string) []string {
+	var targets []string
+	for _, part := range strings.Split(value, ",") {
+		if len(targets) > 0 && strings.HasPrefix(strings.TrimSpace(part), "/") && strings.Contains(targets[len(targets)-1], "|") {
+			targets[len(targets)-1] += "," + part
+			continue
+		}
+		targets = append(targets, part)
 	}
+	return targets
+}
 
-	scannerInstance.Scan()
+func readLines(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(string(content)), "\n"), nil
 }
@@ -0,0 +1,155 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+)
+
+type TargetBaseline struct {
+	Target              string          `json:"target"`
+	HTTP3Target         string          `json:"http3_target,omitempty"`
+	SimilarityThreshold float64         `json:"similarity_threshold"`
+	Probes              []ProbeBaseline `json:"probes"`
+	Error               string          `json:"error,omitempty"`
+}
+
+type ProbeBaseline struct {
+	Variant     string               `json:"variant"`
+	Path        string               `json:"path"`
+	Samples     []BaselineSample     `json:"samples"`
+	StatusCodes []int                `json:"status_codes"`
+	Titles      []string             `json:"titles"`
+	Clusters    []BaselineCluster    `json:"clusters"`
+	Similarity  []BaselineSimilarity `json:"similarity"`
+}
+
+type BaselineSample struct {
+	VHost         string        `json:"vhost"`
+	Request       *SentRequest  `json:"request,omitempty"`
+	StatusCode    int           `json:"status_code"`
+	Title         string        `json:"title"`
+	ContentLength int           `json:"content_length"`
+	BodyLength    int           `json:"body_length"`
+	Protocol      string        `json:"protocol"`
+	Fingerprint   string        `json:"fingerprint"`
+	ResponseTime  int64         `json:"response_time_ms"`
+	Headers       []HeaderField `json:"headers"`
+	Body          string        `json:"body"`
+	Error         string        `json:"error,omitempty"`
+}
+
+type BaselineCluster struct {
+	Fingerprint string   `json:"fingerprint"`
+	StatusCode  int      `json:"status_code"`
+	Title       string   `json:"title"`
+	BodyLength  int      `json:"body_length"`
+	VHosts      []string `json:"vhosts"`
+}
+
+type BaselineSimilarity struct {
+	First      string  `json:"first"`
+	Second     string  `json:"second"`
+	Similarity float64 `json:"similarity"`
+}
+
+func NewBaselineSample(vhost string, response *FullResponse, err error) BaselineSample {
+	if err != nil {
+		return BaselineSample{VHost: vhost, Error: err.Error()}
+	}
+
+	return BaselineSample{
+		VHost:         vhost,
+		Request:       response.Request,
+		StatusCode:    response.StatusCode,
+		Title:         response.Title,
+		ContentLength: response.ContentLength,
+		BodyLength:    len(response.Body),
+		Protocol:      response.Protocol,
+		Fingerprint:   response.fingerprint(),
+		ResponseTime:  response.Duration.Milliseconds(),
+		Headers:       headerFields(response.Headers),
+		Body:          response.Body,
+	}
+}
+
+func (s *Scanner) LearnBaselines(ctx context.Context, target string) (*TargetBaseline, error) {
+	headers, err := s.requester.AliveCheck(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("target %s is not alive: %w", target, err)
+	}
+
+	session := NewSession(s, target)
+	if s.Options.HTTP3 {
+		session.detectHTTP3(ctx, headers.Get("Alt-Svc"))
+	}
+
+	learned := &TargetBaseline{
+		Target:              target,
+		HTTP3Target:         session.HTTP3Target,
+		SimilarityThreshold: s.Options.SimilarityThreshold,
+	}
+	for _, variant := range s.Variants() {
+		for _, path := range session.Paths {
+			baseline, samples := session.sampleBaseline(ctx, ProbeKey{Variant: variant, Path: path})
+			learned.Probes = append(learned.Probes, ProbeBaseline{
+				Variant:     variant,
+				Path:        path,
+				Samples:     samples,
+				StatusCodes: baseline.StatusCodes,
+				Titles:      baseline.Titles,
+				Clusters:    clusterBaselineSamples(samples),
+				Similarity:  pairwiseSimilarity(samples),
+			})
+		}
+	}
+
+	return learned, ctx.Err()
+}
+
+func clusterBaselineSamples(samples []BaselineSample) []BaselineCluster {
+	var clusters []BaselineCluster
+	index := make(map[string]int)
+
+	for _, sample := range samples {
+		if sample.Error != "" {
+			continue
+		}
+
+		position, ok := index[sample.Fingerprint]
+		if !ok {
+			position = len(clusters)
+			index[sample.Fingerprint] = position
+			clusters = append(clusters, BaselineCluster{
+				Fingerprint: sample.Fingerprint,
+				StatusCode:  sample.StatusCode,
+				Title:       sample.Title,
+				BodyLength:  sample.BodyLength,
+			})
+		}
+		clusters[position].VHosts = append(clusters[position].VHosts, sample.VHost)
+	}
+
+	return clusters
+}
+
+func pairwiseSimilarity(samples []BaselineSample) []BaselineSimilarity {
+	var scores []BaselineSimilarity
+
+	for i, first := range samples {
+		if first.Error != "" {
+			continue
+		}
+		for _, second := range samples[i+1:] {
+			if second.Error != "" {
+				continue
+			}
+			scores = append(scores, BaselineSimilarity{
+				First:      first.VHost,
+				Second:     second.VHost,
+				Similarity: CalculateSimilarity(first.Body, second.Body),
+			})
+		}
+	}
+
+	return scores
+}
@@ -0,0 +1,320 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const checkpointInterval = 10 * time.Second
+
+type Checkpoint struct {
+	UpdatedAt time.Time                    `json:"updated_at"`
+	Inputs    *CheckpointInputs            `json:"inputs,omitempty"`
+	Targets   map[string]*TargetCheckpoint `json:"targets"`
+}
+
+type CheckpointInputs struct {
+	Targets        string `json:"targets"`
+	TargetCount    int    `json:"target_count"`
+	Wordlist       string `json:"wordlist"`
+	WordlistLength int    `json:"wordlist_length"`
+	Internal       bool   `json:"internal,omitempty"`
+	Scanned        string `json:"scanned,omitempty"`
+	ScannedLength  int    `json:"scanned_length,omitempty"`
+}
+
+func NewCheckpointInputs(targets []string, wordlist []string, internal bool) *CheckpointInputs {
+	inputs := &CheckpointInputs{
+		Targets:        hashList(targets),
+		TargetCount:    len(targets),
+		Wordlist:       hashList(wordlist),
+		WordlistLength: len(wordlist),
+		Internal:       internal,
+	}
+	if !internal {
+		inputs.Scanned = inputs.Wordlist
+		inputs.ScannedLength = inputs.WordlistLength
+	}
+	return inputs
+}
+
+func hashList(items []string) string {
+	hash := sha256.New()
+	for _, item := range items {
+		hash.Write([]byte(item))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (i *CheckpointInputs) compare(saved *CheckpointInputs) error {
+	if i.Wordlist != saved.Wordlist {
+		return fmt.Errorf("the wordlist differs from the interrupted scan (%d hostnames now, %d in the state file)",
+			i.WordlistLength, saved.WordlistLength)
+	}
+	if i.Targets != saved.Targets {
+		return fmt.Errorf("the targets differ from the interrupted scan (%d targets now, %d in the state file)",
+			i.TargetCount, saved.TargetCount)
+	}
+	if i.Internal != saved.Internal {
+		return fmt.Errorf("internal host filtering differs from the interrupted scan")
+	}
+	return nil
+}
+
+type TargetCheckpoint struct {
+	Completed   bool                 `json:"completed"`
+	Offset      int                  `json:"offset"`
+	Hits        []CheckpointHit      `json:"hits"`
+	Baselines   []CheckpointBaseline `json:"baselines"`
+	HTTP3Target string               `json:"http3_target,omitempty"`
+
+	done map[int]bool
+}
+
+type CheckpointHit struct {
+	Index  int         `json:"index"`
+	Result VHostResult `json:"result"`
+}
+
+type CheckpointBaseline struct {
+	Variant  string           `json:"variant"`
+	Path     string           `json:"path"`
+	Baseline BaselineResponse `json:"baseline"`
+}
+
+type Checkpointer struct {
+	path    string
+	mutex   sync.Mutex
+	state   Checkpoint
+	saved   *CheckpointInputs
+	dirty   bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	onError func(error)
+}
+
+func NewCheckpointer(path string, resume bool, inputs *CheckpointInputs, onError func(error)) (*Checkpointer, error) {
+	checkpointer := &Checkpointer{
+		path:    path,
+		state:   Checkpoint{Targets: make(map[string]*TargetCheckpoint)},
+		stop:    make(chan struct{}),
+		onError: onError,
+	}
+
+	if resume {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state file: %w", err)
+		}
+
+		if err := json.Unmarshal(content, &checkpointer.state); err != nil {
+			return nil, fmt.Errorf("failed to parse state file: %w", err)
+		}
+
+		checkpointer.saved = checkpointer.state.Inputs
+
+		switch {
+		case inputs != nil && checkpointer.saved != nil:
+			if err := inputs.compare(checkpointer.saved); err != nil {
+				return nil, fmt.Errorf("cannot resume from %s: %w", path, err)
+			}
+			if inputs.Scanned == "" {
+				inputs.Scanned = checkpointer.saved.Scanned
+				inputs.ScannedLength = checkpointer.saved.ScannedLength
+			}
+		case onError != nil:
+			onError(fmt.Errorf("the inputs were streamed and cannot be checked against %s, make sure they are the same as in the interrupted scan", path))
+		}
+
+		if checkpointer.state.Targets == nil {
+			checkpointer.state.Targets = make(map[string]*TargetCheckpoint)
+		}
+
+		for _, state := range checkpointer.state.Targets {
+			if state.Completed {
+				continue
+			}
+
+			var hits []CheckpointHit
+			for _, hit := range state.Hits {
+				if hit.Index < state.Offset {
+					hits = append(hits, hit)
+				}
+			}
+			state.Hits = hits
+		}
+	}
+
+	checkpointer.state.Inputs = inputs
+
+	checkpointer.wg.Add(1)
+	go checkpointer.saveLoop()
+
+	return checkpointer, nil
+}
+
+func (c *Checkpointer) saveLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Save(); err != nil && c.onError != nil {
+				c.onError(err)
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *Checkpointer) SetScannedWordlist(wordlist []string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	scanned := hashList(wordlist)
+	if c.saved != nil && c.saved.Scanned != "" && c.saved.Scanned != scanned {
+		return fmt.Errorf("cannot resume from %s: the filtered wordlist differs from the interrupted scan (%d hostnames now, %d in the state file)",
+			c.path, len(wordlist), c.saved.ScannedLength)
+	}
+
+	if c.state.Inputs != nil {
+		c.state.Inputs.Scanned = scanned
+		c.state.Inputs.ScannedLength = len(wordlist)
+		c.dirty = true
+	}
+	return nil
+}
+
+func (c *TargetCheckpoint) RestoredBaselines() map[ProbeKey]BaselineResponse {
+	baselines := make(map[ProbeKey]BaselineResponse)
+	for _, baseline := range c.Baselines {
+		baselines[ProbeKey{Variant: baseline.Variant, Path: baseline.Path}] = baseline.Baseline
+	}
+	return baselines
+}
+
+func (c *TargetCheckpoint) RestoredResults() []SessionResult {
+	var results []SessionResult
+	for _, hit := range c.Hits {
+		results = append(results, hit.Result.SessionResult())
+	}
+	return results
+}
+
+func (c *Checkpointer) Target(target string) (TargetCheckpoint, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state, ok := c.state.Targets[target]
+	if !ok {
+		return TargetCheckpoint{}, false
+	}
+	return *state, true
+}
+
+func (c *Checkpointer) target(target string) *TargetCheckpoint {
+	state, ok := c.state.Targets[target]
+	if !ok {
+		state = &TargetCheckpoint{}
+		c.state.Targets[target] = state
+	}
+	if state.done == nil {
+		state.done = make(map[int]bool)
+	}
+	return state
+}
+
+func (c *Checkpointer) SetBaselines(target string, baselines map[ProbeKey]BaselineResponse, http3Target string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state := c.target(target)
+	state.Baselines = nil
+	for probe, baseline := range baselines {
+		state.Baselines = append(state.Baselines, CheckpointBaseline{
+			Variant:  probe.Variant,
+			Path:     probe.Path,
+			Baseline: baseline,
+		})
+	}
+	state.HTTP3Target = http3Target
+	c.dirty = true
+}
+
+func (c *Checkpointer) MarkDone(target string, index int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state := c.target(target)
+	state.done[index] = true
+	for state.done[state.Offset] {
+		delete(state.done, state.Offset)
+		state.Offset++
+	}
+	c.dirty = true
+}
+
+func (c *Checkpointer) AddHit(target string, index int, hit VHostResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state := c.target(target)
+	state.Hits = append(state.Hits, CheckpointHit{Index: index, Result: hit})
+	c.dirty = true
+}
+
+func (c *Checkpointer) CompleteTarget(target string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state := c.target(target)
+	state.Completed = true
+	state.done = nil
+	c.dirty = true
+}
+
+func (c *Checkpointer) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	c.state.UpdatedAt = time.Now()
+	content, err := json.Marshal(c.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	err = writeFileAtomic(c.path, func(file io.Writer) error {
+		if _, err := file.Write(content); err != nil {
+			return fmt.Errorf("failed to write state file: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
+
+func (c *Checkpointer) Close() error {
+	close(c.stop)
+	c.wg.Wait()
+
+	return c.Save()
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointResumeChecksScannedWordlist(t *testing.T) {
+	targets := []string{"https://example.com"}
+	wordlist := []string{"www.example.com", "admin.example.com", "mail.example.com"}
+	filtered := []string{"admin.example.com", "mail.example.com"}
+
+	tests := []struct {
+		name     string
+		internal bool
+		resumed  bool
+		scanned  []string
+		err      string
+	}{
+		{"same filtered wordlist", true, true, filtered, ""},
+		{"different filtered wordlist", true, true, []string{"admin.example.com"}, "the filtered wordlist differs from the interrupted scan (1 hostnames now, 2 in the state file)"},
+		{"unfiltered wordlist", true, true, wordlist, "the filtered wordlist differs from the interrupted scan (3 hostnames now, 2 in the state file)"},
+		{"filtering toggled", false, false, nil, "internal host filtering differs from the interrupted scan"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+
+			checkpointer, err := NewCheckpointer(path, false, NewCheckpointInputs(targets, wordlist, true), nil)
+			if err != nil {
+				t.Fatalf("NewCheckpointer: %v", err)
+			}
+			if err := checkpointer.SetScannedWordlist(filtered); err != nil {
+				t.Fatalf("SetScannedWordlist: %v", err)
+			}
+			checkpointer.MarkDone(targets[0], 0)
+			if err := checkpointer.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			resumed, err := NewCheckpointer(path, true, NewCheckpointInputs(targets, wordlist, test.internal), nil)
+			if !test.resumed {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("NewCheckpointer error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewCheckpointer: %v", err)
+			}
+			defer resumed.Close()
+
+			err = resumed.SetScannedWordlist(test.scanned)
+			if test.err == "" && err != nil {
+				t.Errorf("SetScannedWordlist = %v, want no error", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("SetScannedWordlist error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
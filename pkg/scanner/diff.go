@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type ScanDiff struct {
+	Added   []HitRecord   `json:"added"`
+	Removed []HitRecord   `json:"removed"`
+	Changed []VHostChange `json:"changed"`
+}
+
+type VHostChange struct {
+	Target  string        `json:"target"`
+	VHost   string        `json:"vhost"`
+	Path    string        `json:"path"`
+	Variant string        `json:"variant"`
+	Fields  []FieldChange `json:"fields"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+func LoadHitRecords(path string) ([]HitRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer file.Close()
+
+	hits, err := ReadHitRecords(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return hits, nil
+}
+
+func ReadHitRecords(reader io.Reader) ([]HitRecord, error) {
+	targets, _, err := ParseOutputFile(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []HitRecord
+	for _, target := range targets {
+		for _, entry := range target.VHosts {
+			hits = append(hits, HitRecord{Target: target.Target, VHostResult: entry})
+		}
+	}
+	return hits, nil
+}
+
+func DiffHitRecords(oldHits []HitRecord, newHits []HitRecord) ScanDiff {
+	index := func(hits []HitRecord) map[string]HitRecord {
+		indexed := make(map[string]HitRecord)
+		for _, hit := range hits {
+			indexed[hit.Target+"\x00"+hit.key()] = hit
+		}
+		return indexed
+	}
+
+	oldIndex := index(oldHits)
+	newIndex := index(newHits)
+
+	diff := ScanDiff{Added: []HitRecord{}, Removed: []HitRecord{}, Changed: []VHostChange{}}
+	for key, hit := range newIndex {
+		previous, ok := oldIndex[key]
+		if !ok {
+			diff.Added = append(diff.Added, hit)
+			continue
+		}
+
+		if fields := compareVHostResults(previous.VHostResult, hit.VHostResult); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, VHostChange{
+				Target:  hit.Target,
+				VHost:   hit.VHost,
+				Path:    hit.Path,
+				Variant: hit.Variant,
+				Fields:  fields,
+			})
+		}
+	}
+	for key, hit := range oldIndex {
+		if _, ok := newIndex[key]; !ok {
+			diff.Removed = append(diff.Removed, hit)
+		}
+	}
+
+	sortHits := func(hits []HitRecord) {
+		sort.Slice(hits, func(i, j int) bool {
+			return hits[i].Target+"\x00"+hits[i].key() < hits[j].Target+"\x00"+hits[j].key()
+		})
+	}
+	sortHits(diff.Added)
+	sortHits(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		a, b := diff.Changed[i], diff.Changed[j]
+		return a.Target+"\x00"+a.VHost+"\x00"+a.Path+"\x00"+a.Variant < b.Target+"\x00"+b.VHost+"\x00"+b.Path+"\x00"+b.Variant
+	})
+
+	return diff
+}
+
+func compareVHostResults(previous VHostResult, current VHostResult) []FieldChange {
+	var fields []FieldChange
+	if previous.StatusCode != current.StatusCode {
+		fields = append(fields, FieldChange{Field: "status_code", Old: previous.StatusCode, New: current.StatusCode})
+	}
+	if previous.Title != current.Title {
+		fields = append(fields, FieldChange{Field: "title", Old: previous.Title, New: current.Title})
+	}
+	if previous.ContentLength != current.ContentLength {
+		fields = append(fields, FieldChange{Field: "content_length", Old: previous.ContentLength, New: current.ContentLength})
+	}
+	if previous.Fingerprint != current.Fingerprint {
+		fields = append(fields, FieldChange{Field: "fingerprint", Old: previous.Fingerprint, New: current.Fingerprint})
+	}
+	return fields
+}
+
+func (d ScanDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffOldOutput = `{"target":"https://10.0.0.1","vhosts":[` +
+	`{"vhost":"admin.example.com","path":"/","variant":"host","status_code":200,"title":"Admin","content_length":512,"fingerprint":"aaaa"},` +
+	`{"vhost":"admin.example.com","path":"/","variant":"x-forwarded-host","status_code":200,"title":"Admin","content_length":512,"fingerprint":"aaaa"},` +
+	`{"vhost":"old.example.com","path":"/","variant":"host","status_code":200,"title":"Legacy","content_length":90,"fingerprint":"bbbb"}]}
+{"target":"https://10.0.0.2","vhosts":[` +
+	`{"vhost":"api.example.com","path":"/v1","variant":"host","status_code":401,"title":"","content_length":12,"fingerprint":"cccc"}]}
+{"type":"origin","origin":{"hostname":"www.example.com","reference":null,"candidates":[]}}
+`
+
+const diffNewOutput = `{"target":"https://10.0.0.1","vhost":"admin.example.com","path":"/","variant":"host","status_code":302,"title":"Login","content_length":512,"fingerprint":"dddd"}
+{"target":"https://10.0.0.1","vhost":"admin.example.com","path":"/","variant":"x-forwarded-host","status_code":200,"title":"Admin","content_length":512,"fingerprint":"aaaa"}
+{"target":"https://10.0.0.2","vhost":"api.example.com","path":"/v1","variant":"host","status_code":401,"title":"","content_length":14,"fingerprint":"cccc"}
+{"target":"https://10.0.0.2","vhost":"api.example.com","path":"/v2","variant":"host","status_code":200,"title":"API","content_length":40,"fingerprint":"eeee"}
+{"target":"https://10.0.0.3","vhost":"old.example.com","path":"/","variant":"host","status_code":200,"title":"Legacy","content_length":90,"fingerprint":"bbbb"}
+`
+
+func TestDiffHitRecords(t *testing.T) {
+	oldHits, err := ReadHitRecords(strings.NewReader(diffOldOutput))
+	if err != nil {
+		t.Fatalf("ReadHitRecords(old): %v", err)
+	}
+	newHits, err := ReadHitRecords(strings.NewReader(diffNewOutput))
+	if err != nil {
+		t.Fatalf("ReadHitRecords(new): %v", err)
+	}
+
+	diff := DiffHitRecords(oldHits, newHits)
+
+	hitKeys := func(hits []HitRecord) []string {
+		var keys []string
+		for _, hit := range hits {
+			keys = append(keys, hit.Target+" "+hit.VHost+hit.Path+" "+hit.Variant)
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"added", hitKeys(diff.Added), []string{
+			"https://10.0.0.2 api.example.com/v2 host",
+			"https://10.0.0.3 old.example.com/ host",
+		}},
+		{"removed", hitKeys(diff.Removed), []string{
+			"https://10.0.0.1 old.example.com/ host",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if strings.Join(test.got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("%s:\n%s\nwant:\n%s", test.name, strings.Join(test.got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+
+	changes := []struct {
+		target string
+		vhost  string
+		path   string
+		fields []FieldChange
+	}{
+		{"https://10.0.0.1", "admin.example.com", "/", []FieldChange{
+			{Field: "status_code", Old: 200, New: 302},
+			{Field: "title", Old: "Admin", New: "Login"},
+			{Field: "fingerprint", Old: "aaaa", New: "dddd"},
+		}},
+		{"https://10.0.0.2", "api.example.com", "/v1", []FieldChange{
+			{Field: "content_length", Old: int64(12), New: int64(14)},
+		}},
+	}
+
+	if len(diff.Changed) != len(changes) {
+		t.Fatalf("got %d changed hits, want %d: %+v", len(diff.Changed), len(changes), diff.Changed)
+	}
+	for i, want := range changes {
+		got := diff.Changed[i]
+		if got.Target != want.target || got.VHost != want.vhost || got.Path != want.path || got.Variant != VariantHost {
+			t.Errorf("change %d is for %s %s%s (%s), want %s %s%s (host)", i, got.Target, got.VHost, got.Path, got.Variant,
+				want.target, want.vhost, want.path)
+		}
+		if len(got.Fields) != len(want.fields) {
+			t.Errorf("change %d fields = %+v, want %+v", i, got.Fields, want.fields)
+			continue
+		}
+		for j, field := range want.fields {
+			if got.Fields[j] != field {
+				t.Errorf("change %d field %d = %+v, want %+v", i, j, got.Fields[j], field)
+			}
+		}
+	}
+
+	if !DiffHitRecords(oldHits, oldHits).Empty() {
+		t.Errorf("diff of a file against itself is not empty")
+	}
+}
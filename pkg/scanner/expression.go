@@ -0,0 +1,813 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Expression struct {
+	source string
+	root   expressionNode
+}
+
+type expressionNode interface {
+	eval(env *expressionEnv) (any, error)
+}
+
+type expressionEnv struct {
+	variables map[string]any
+	functions map[string]func(args []any) (any, error)
+}
+
+type headerMap map[string]string
+
+var expressionPatterns sync.Map
+
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &expressionParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.done() {
+		return nil, fmt.Errorf("unexpected %q at position %d", parser.peek().text, parser.peek().position)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+func (e *Expression) Check(variables []string, functions []string) error {
+	var check func(node expressionNode) error
+	check = func(node expressionNode) error {
+		switch node := node.(type) {
+		case variableNode:
+			if !slices.Contains(variables, node.name) {
+				return fmt.Errorf("unknown variable %s", node.name)
+			}
+		case callNode:
+			if !slices.Contains(functions, node.name) {
+				return fmt.Errorf("unknown function %s()", node.name)
+			}
+			for _, arg := range node.args {
+				if err := check(arg); err != nil {
+					return err
+				}
+			}
+		case listNode:
+			for _, item := range node.items {
+				if err := check(item); err != nil {
+					return err
+				}
+			}
+		case indexNode:
+			if err := check(node.target); err != nil {
+				return err
+			}
+			return check(node.index)
+		case notNode:
+			return check(node.operand)
+		case logicalNode:
+			if err := check(node.left); err != nil {
+				return err
+			}
+			return check(node.right)
+		case arithmeticNode:
+			if err := check(node.left); err != nil {
+				return err
+			}
+			return check(node.right)
+		case comparisonNode:
+			if err := check(node.left); err != nil {
+				return err
+			}
+			return check(node.right)
+		}
+		return nil
+	}
+
+	return check(e.root)
+}
+
+func (e *Expression) Evaluate(env *expressionEnv) (bool, error) {
+	value, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluates to %s, not a boolean", typeName(value))
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type expressionToken struct {
+	kind     tokenKind
+	text     string
+	value    any
+	position int
+}
+
+var expressionOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ",", "+", "-", "*", "/"}
+
+func tokenizeExpression(source string) ([]expressionToken, error) {
+	var tokens []expressionToken
+
+	position := func(offset int) int {
+		return utf8.RuneCountInString(source[:offset])
+	}
+
+	for i := 0; i < len(source); {
+		char, size := utf8.DecodeRuneInString(source[i:])
+		if char == utf8.RuneError && size == 1 {
+			return nil, fmt.Errorf("invalid UTF-8 at position %d", position(i))
+		}
+		i += size
+	}
+
+	for i := 0; i < len(source); {
+		char, size := utf8.DecodeRuneInString(source[i:])
+
+		switch {
+		case unicode.IsSpace(char):
+			i += size
+
+		case char >= '0' && char <= '9':
+			start := i
+			for i < len(source) && (isASCIIDigit(source[i]) || source[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", source[start:i], position(start))
+			}
+			tokens = append(tokens, expressionToken{kind: tokenNumber, text: source[start:i], value: number, position: position(start)})
+
+		case char == '"' || char == '\'':
+			start := i
+			i++
+			for i < len(source) && rune(source[i]) != char {
+				if source[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(source) {
+				return nil, fmt.Errorf("unterminated string at position %d", position(start))
+			}
+			i++
+
+			literal := source[start:i]
+			if char == '\'' {
+				literal = `"` + strings.ReplaceAll(strings.ReplaceAll(literal[1:len(literal)-1], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			value, err := strconv.Unquote(literal)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s at position %d", source[start:i], position(start))
+			}
+			tokens = append(tokens, expressionToken{kind: tokenString, text: source[start:i], value: value, position: position(start)})
+
+		case unicode.IsLetter(char) || char == '_':
+			start := i
+			for i < len(source) {
+				next, size := utf8.DecodeRuneInString(source[i:])
+				if !unicode.IsLetter(next) && !unicode.IsDigit(next) && next != '_' && next != '.' {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, expressionToken{kind: tokenIdent, text: source[start:i], position: position(start)})
+
+		default:
+			matched := false
+			for _, operator := range expressionOperators {
+				if strings.HasPrefix(source[i:], operator) {
+					tokens = append(tokens, expressionToken{kind: tokenOperator, text: operator, position: position(i)})
+					i += len(operator)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", char, position(i))
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+func isASCIIDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+type expressionParser struct {
+	tokens []expressionToken
+	index  int
+}
+
+func (p *expressionParser) done() bool {
+	return p.index >= len(p.tokens)
+}
+
+func (p *expressionParser) peek() expressionToken {
+	if p.done() {
+		return expressionToken{text: "end of expression", position: -1}
+	}
+	return p.tokens[p.index]
+}
+
+func (p *expressionParser) accept(texts ...string) (string, bool) {
+	if p.done() {
+		return "", false
+	}
+
+	token := p.tokens[p.index]
+	if token.kind != tokenOperator && token.kind != tokenIdent {
+		return "", false
+	}
+
+	for _, text := range texts {
+		if token.text == text {
+			p.index++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *expressionParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		token := p.peek()
+		return fmt.Errorf("expected %q but found %q at position %d", text, token.text, token.position)
+	}
+	return nil
+}
+
+func (p *expressionParser) parseOr() (expressionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{operator: "||", left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseAnd() (expressionNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{operator: "&&", left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseNot() (expressionNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *expressionParser) parseComparison() (expressionNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	operator, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "in", "contains", "matches", "startsWith", "endsWith")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return comparisonNode{operator: operator, left: left, right: right}, nil
+}
+
+func (p *expressionParser) parseAdditive() (expressionNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseMultiplicative() (expressionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return arithmeticNode{operator: "-", left: literalNode{value: 0.0}, right: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *expressionParser) parsePostfix() (expressionNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("["); !ok {
+			return node, nil
+		}
+		index, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		node = indexNode{target: node, index: index}
+	}
+}
+
+func (p *expressionParser) parsePrimary() (expressionNode, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	token := p.tokens[p.index]
+	p.index++
+
+	switch token.kind {
+	case tokenNumber, tokenString:
+		return literalNode{value: token.value}, nil
+
+	case tokenIdent:
+		switch token.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+
+		if _, ok := p.accept("("); !ok {
+			return variableNode{name: token.text}, nil
+		}
+
+		var args []expressionNode
+		if _, ok := p.accept(")"); ok {
+			return callNode{name: token.text}, nil
+		}
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return callNode{name: token.text, args: args}, nil
+
+	case tokenOperator:
+		switch token.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return node, nil
+
+		case "[":
+			var items []expressionNode
+			if _, ok := p.accept("]"); ok {
+				return listNode{}, nil
+			}
+			for {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+
+				if _, ok := p.accept(","); !ok {
+					break
+				}
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return listNode{items: items}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.position)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(env *expressionEnv) (any, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n variableNode) eval(env *expressionEnv) (any, error) {
+	value, ok := env.variables[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %s", n.name)
+	}
+	return value, nil
+}
+
+type listNode struct {
+	items []expressionNode
+}
+
+func (n listNode) eval(env *expressionEnv) (any, error) {
+	values := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+type callNode struct {
+	name string
+	args []expressionNode
+}
+
+func (n callNode) eval(env *expressionEnv) (any, error) {
+	function, ok := env.functions[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s()", n.name)
+	}
+
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	return function(args)
+}
+
+type indexNode struct {
+	target expressionNode
+	index  expressionNode
+}
+
+func (n indexNode) eval(env *expressionEnv) (any, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target := target.(type) {
+	case headerMap:
+		name, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("headers must be indexed by a string, not %s", typeName(index))
+		}
+		return target[http.CanonicalHeaderKey(name)], nil
+
+	case []any:
+		position, ok := index.(float64)
+		if !ok {
+			return nil, fmt.Errorf("lists must be indexed by a number, not %s", typeName(index))
+		}
+		if position < 0 || int(position) >= len(target) {
+			return nil, fmt.Errorf("index %d out of range", int(position))
+		}
+		return target[int(position)], nil
+	}
+
+	return nil, fmt.Errorf("cannot index %s", typeName(target))
+}
+
+type notNode struct {
+	operand expressionNode
+}
+
+func (n notNode) eval(env *expressionEnv) (any, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", typeName(value))
+	}
+	return !result, nil
+}
+
+type logicalNode struct {
+	operator string
+	left     expressionNode
+	right    expressionNode
+}
+
+func (n logicalNode) eval(env *expressionEnv) (any, error) {
+	left, err := evalBool(n.left, env, n.operator)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.operator == "&&" && !left {
+		return false, nil
+	}
+	if n.operator == "||" && left {
+		return true, nil
+	}
+
+	return evalBool(n.right, env, n.operator)
+}
+
+func evalBool(node expressionNode, env *expressionEnv, operator string) (bool, error) {
+	value, err := node.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s needs booleans, not %s", operator, typeName(value))
+	}
+	return result, nil
+}
+
+type arithmeticNode struct {
+	operator string
+	left     expressionNode
+	right    expressionNode
+}
+
+func (n arithmeticNode) eval(env *expressionEnv) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.operator == "+" {
+		if leftString, ok := left.(string); ok {
+			if rightString, ok := right.(string); ok {
+				return leftString + rightString, nil
+			}
+		}
+	}
+
+	leftNumber, leftOK := left.(float64)
+	rightNumber, rightOK := right.(float64)
+	if !leftOK || !rightOK {
+		return nil, fmt.Errorf("operator %s needs numbers, not %s and %s", n.operator, typeName(left), typeName(right))
+	}
+
+	switch n.operator {
+	case "+":
+		return leftNumber + rightNumber, nil
+	case "-":
+		return leftNumber - rightNumber, nil
+	case "*":
+		return leftNumber * rightNumber, nil
+	default:
+		if rightNumber == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return leftNumber / rightNumber, nil
+	}
+}
+
+type comparisonNode struct {
+	operator string
+	left     expressionNode
+	right    expressionNode
+}
+
+func (n comparisonNode) eval(env *expressionEnv) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+
+	case "<", "<=", ">", ">=":
+		leftNumber, leftOK := left.(float64)
+		rightNumber, rightOK := right.(float64)
+		if !leftOK || !rightOK {
+			return nil, fmt.Errorf("operator %s needs numbers, not %s and %s", n.operator, typeName(left), typeName(right))
+		}
+		switch n.operator {
+		case "<":
+			return leftNumber < rightNumber, nil
+		case "<=":
+			return leftNumber <= rightNumber, nil
+		case ">":
+			return leftNumber > rightNumber, nil
+		default:
+			return leftNumber >= rightNumber, nil
+		}
+
+	case "in":
+		switch container := right.(type) {
+		case []any:
+			for _, item := range container {
+				if valuesEqual(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case headerMap:
+			name, ok := left.(string)
+			if !ok {
+				return nil, fmt.Errorf("header names must be strings, not %s", typeName(left))
+			}
+			_, found := container[http.CanonicalHeaderKey(name)]
+			return found, nil
+		case string:
+			needle, ok := left.(string)
+			if !ok {
+				return nil, fmt.Errorf("operator in needs a string on the left of a string, not %s", typeName(left))
+			}
+			return strings.Contains(container, needle), nil
+		}
+		return nil, fmt.Errorf("operator in needs a list, headers or a string on the right, not %s", typeName(right))
+	}
+
+	leftString, leftOK := left.(string)
+	rightString, rightOK := right.(string)
+	if !leftOK || !rightOK {
+		return nil, fmt.Errorf("operator %s needs strings, not %s and %s", n.operator, typeName(left), typeName(right))
+	}
+
+	switch n.operator {
+	case "contains":
+		return strings.Contains(leftString, rightString), nil
+	case "startsWith":
+		return strings.HasPrefix(leftString, rightString), nil
+	case "endsWith":
+		return strings.HasSuffix(leftString, rightString), nil
+	default:
+		pattern, err := compileExpressionPattern(rightString)
+		if err != nil {
+			return nil, err
+		}
+		return pattern.MatchString(leftString), nil
+	}
+}
+
+func compileExpressionPattern(source string) (*regexp.Regexp, error) {
+	if cached, ok := expressionPatterns.Load(source); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	pattern, err := regexp.Compile(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", source, err)
+	}
+	expressionPatterns.Store(source, pattern)
+	return pattern, nil
+}
+
+func valuesEqual(left any, right any) bool {
+	switch left := left.(type) {
+	case float64:
+		right, ok := right.(float64)
+		return ok && left == right
+	case string:
+		right, ok := right.(string)
+		return ok && left == right
+	case bool:
+		right, ok := right.(bool)
+		return ok && left == right
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []any:
+		return "list"
+	case headerMap:
+		return "headers"
+	case nil:
+		return "nothing"
+	}
+	return fmt.Sprintf("%T", value)
+}
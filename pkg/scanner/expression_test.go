@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testRuleEnv() *expressionEnv {
+	session := &Session{Target: "https://example.com"}
+	baseline := BaselineResponse{
+		StatusCodes: []int{404, 200},
+		Titles:      []string{"Not Found"},
+		Bodies:      []string{"<title>Not Found</title> nothing here"},
+	}
+	response := &FullResponse{
+		Body:          "<title>Café Admin</title> panel",
+		Title:         "Café Admin",
+		StatusCode:    http.StatusOK,
+		ContentLength: 1337,
+		Protocol:      "HTTP/1.1",
+		Variant:       VariantHost,
+		Headers:       http.Header{"Server": {"nginx"}, "X-Backend": {"app-12"}},
+	}
+
+	return session.ruleEnv(baseline, "admin.example.com", "/", response)
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{`response.status ==`, "unexpected end of expression"},
+		{`(1 == 1`, `expected ")" but found "end of expression"`},
+		{`1 == 1 )`, `unexpected ")" at position 7`},
+		{`"abc`, "unterminated string at position 0"},
+		{`"\q" == vhost`, `invalid string "\q" at position 0`},
+		{`1.2.3 == 1`, `invalid number "1.2.3" at position 0`},
+		{`vhost == "é" && #`, "unexpected character '#' at position 16"},
+		{`vhost == "é" && ©`, "unexpected character '©' at position 16"},
+		{"vhost == \"\xff\"", "invalid UTF-8 at position 10"},
+		{`[1, 2`, `expected "]" but found "end of expression"`},
+		{`lower(vhost`, `expected ")" but found "end of expression"`},
+		{`== 1`, `unexpected "==" at position 0`},
+		{`1 < 2 == true`, `unexpected "==" at position 6`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			_, err := ParseExpression(test.source)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseExpression(%q) error = %v, want %q", test.source, err, test.err)
+			}
+		})
+	}
+}
+
+func TestExpressionCheck(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{`response.status == 200 && lower(response.title) contains "admin"`, ""},
+		{`response.headers["Server"] in [vhost, path]`, ""},
+		{`café == 1`, "unknown variable café"},
+		{`response.statuss == 200`, "unknown variable response.statuss"},
+		{`upper(vhost) == "A"`, "unknown function upper()"},
+		{`!(len([response.nope]) > 0)`, "unknown variable response.nope"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			expression, err := ParseExpression(test.source)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q): %v", test.source, err)
+			}
+
+			err = expression.Check(RuleVariables, RuleFunctions)
+			if test.err == "" && err != nil {
+				t.Errorf("Check(%q) = %v, want no error", test.source, err)
+			}
+			if test.err != "" && (err == nil || err.Error() != test.err) {
+				t.Errorf("Check(%q) = %v, want %q", test.source, err, test.err)
+			}
+		})
+	}
+}
+
+func TestExpressionEvaluate(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`1 + 2 * 3 == 7`, true},
+		{`(1 + 2) * 3 == 9`, true},
+		{`-2 * 3 == -6`, true},
+		{`10 - 4 - 3 == 3`, true},
+		{`12 / 3 / 2 == 2`, true},
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`!false && false`, false},
+		{`not true or true`, true},
+		{`!(1 == 2)`, true},
+		{`"a" + "b" == "ab"`, true},
+		{`1 == "1"`, false},
+		{`false && nothing()`, false},
+		{`true || nothing()`, true},
+		{`response.status in [200, 301]`, true},
+		{`response.status in baseline.statuses && !(response.title in baseline.titles)`, true},
+		{`response.headers["server"] == "nginx"`, true},
+		{`response.headers["X-Missing"] == ""`, true},
+		{`"x-backend" in response.headers`, true},
+		{`header("X-Backend") matches "^app-[0-9]+$"`, true},
+		{`response.title contains "Admin" and response.title startsWith "Café"`, true},
+		{`response.title endsWith "Admin"`, true},
+		{`"é A" in response.title`, true},
+		{`lower(response.title) == "café admin"`, true},
+		{`len(response.title) == 11`, true},
+		{`len(baseline.statuses) == 2 && baseline.statuses[1] == 200`, true},
+		{`similarity() < 60`, true},
+		{`similarity(response.body, response.body) == 100`, true},
+		{`response.length == 32 && baseline.length == 37`, true},
+		{`baseline.lengths[0] == baseline.length`, true},
+		{`vhost endsWith ".example.com" && target == "https://example.com" && path == "/"`, true},
+	}
+
+	env := testRuleEnv()
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			expression, err := ParseExpression(test.source)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q): %v", test.source, err)
+			}
+
+			got, err := expression.Evaluate(env)
+			if err != nil {
+				t.Fatalf("Evaluate(%q): %v", test.source, err)
+			}
+			if got != test.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpressionTypeErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{`1 + 1`, "expression evaluates to number, not a boolean"},
+		{`1 + "a" == 2`, "operator + needs numbers, not number and string"},
+		{`"a" < "b"`, "operator < needs numbers, not string and string"},
+		{`!1`, "cannot negate number"},
+		{`1 && true`, "operator && needs booleans, not number"},
+		{`true || "a" == 1 || 2`, ""},
+		{`false || 2`, "operator || needs booleans, not number"},
+		{`1 / 0 == 1`, "division by zero"},
+		{`1 contains "a"`, "operator contains needs strings, not number and string"},
+		{`response.title matches "("`, `invalid regular expression "("`},
+		{`1 in 2`, "operator in needs a list, headers or a string on the right, not number"},
+		{`1 in response.headers`, "header names must be strings, not number"},
+		{`[1][5] == 1`, "index 5 out of range"},
+		{`[1]["a"] == 1`, "lists must be indexed by a number, not string"},
+		{`response.headers[1] == ""`, "headers must be indexed by a string, not number"},
+		{`vhost[0] == "a"`, "cannot index string"},
+		{`len(1) == 1`, "len() takes one string, list or headers"},
+		{`nothing() == 1`, "unknown function nothing()"},
+	}
+
+	env := testRuleEnv()
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			expression, err := ParseExpression(test.source)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q): %v", test.source, err)
+			}
+
+			_, err = expression.Evaluate(env)
+			if test.err == "" && err != nil {
+				t.Errorf("Evaluate(%q) = %v, want no error", test.source, err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("Evaluate(%q) error = %v, want %q", test.source, err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const DefaultHARBodyLimit = 64 * 1024
+
+type HARWriter struct {
+	path      string
+	bodyLimit int
+	mutex     sync.Mutex
+	entries   []harEntry
+	dirty     bool
+}
+
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment"`
+}
+
+type harRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []struct{}      `json:"cookies"`
+	Headers     []HeaderField   `json:"headers"`
+	QueryString []harQueryParam `json:"queryString"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+	Comment     string          `json:"comment"`
+}
+
+type harQueryParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harResponse struct {
+	Status      int           `json:"status"`
+	StatusText  string        `json:"statusText"`
+	HTTPVersion string        `json:"httpVersion"`
+	Cookies     []struct{}    `json:"cookies"`
+	Headers     []HeaderField `json:"headers"`
+	Content     harContent    `json:"content"`
+	RedirectURL string        `json:"redirectURL"`
+	HeadersSize int           `json:"headersSize"`
+	BodySize    int           `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func NewHARWriter(path string, bodyLimit int) (*HARWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HAR file: %w", err)
+	}
+	file.Close()
+
+	if bodyLimit <= 0 {
+		bodyLimit = DefaultHARBodyLimit
+	}
+
+	return &HARWriter{path: path, bodyLimit: bodyLimit, dirty: true}, nil
+}
+
+func (w *HARWriter) Add(target string, result SessionResult, response *FullResponse) {
+	if response == nil || response.Request == nil {
+		return
+	}
+
+	request := response.Request
+	content := w.content(response.Body, response.Headers.Get("Content-Type"))
+
+	var queryString []harQueryParam
+	if index := strings.Index(request.URL, "?"); index >= 0 {
+		for _, pair := range strings.Split(request.URL[index+1:], "&") {
+			name, value, _ := strings.Cut(pair, "=")
+			queryString = append(queryString, harQueryParam{Name: name, Value: value})
+		}
+	}
+
+	milliseconds := float64(response.Duration) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: response.StartedAt.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      request.Method,
+			URL:         request.URL,
+			HTTPVersion: request.Proto,
+			Cookies:     []struct{}{},
+			Headers:     request.Headers,
+			QueryString: queryString,
+			HeadersSize: -1,
+			BodySize:    0,
+			Comment:     request.RequestLine,
+		},
+		Response: harResponse{
+			Status:      response.StatusCode,
+			StatusText:  http.StatusText(response.StatusCode),
+			HTTPVersion: response.Protocol,
+			Cookies:     []struct{}{},
+			Headers:     headerFields(response.Headers),
+			Content:     content,
+			RedirectURL: response.Headers.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    response.ContentLength,
+		},
+		Timings: harTimings{Send: 0, Wait: milliseconds, Receive: 0},
+		Comment: fmt.Sprintf("vhost %s on %s (variant: %s, path: %s)", result.VHost, target, response.Variant, result.Path),
+	}
+	if entry.Request.QueryString == nil {
+		entry.Request.QueryString = []harQueryParam{}
+	}
+	if entry.Request.Headers == nil {
+		entry.Request.Headers = []HeaderField{}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.entries = append(w.entries, entry)
+	w.dirty = true
+}
+
+func (w *HARWriter) content(body string, mimeType string) harContent {
+	content := harContent{Size: len(body), MimeType: mimeType}
+
+	if len(body) > w.bodyLimit {
+		cut := w.bodyLimit
+		for cut > w.bodyLimit-utf8.UTFMax && cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+		if !utf8.ValidString(body[:cut]) {
+			cut = w.bodyLimit
+		}
+		body = body[:cut]
+		content.Comment = fmt.Sprintf("body truncated to %d bytes", cut)
+	}
+
+	if utf8.ValidString(body) {
+		content.Text = body
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString([]byte(body))
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+func (w *HARWriter) Close() error {
+	return w.Sync()
+}
+
+func (w *HARWriter) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.dirty {
+		return nil
+	}
+
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "go-vhosts", Version: "1.0"}
+	har.Log.Entries = w.entries
+	if har.Log.Entries == nil {
+		har.Log.Entries = []harEntry{}
+	}
+
+	content, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+
+	err = writeFileAtomic(w.path, func(file io.Writer) error {
+		if _, err := file.Write(content); err != nil {
+			return fmt.Errorf("failed to write HAR file: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.dirty = false
+	return nil
+}
+
+func headerFields(headers http.Header) []HeaderField {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := []HeaderField{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fields = append(fields, HeaderField{Name: name, Value: value})
+		}
+	}
+	return fields
+}
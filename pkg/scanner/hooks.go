@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"context"
+	"slices"
+)
+
+const (
+	PhaseFilter = "filter"
+	PhaseScan   = "scan"
+	PhaseOrigin = "origin"
+	PhaseVerify = "verify"
+)
+
+type Hooks struct {
+	OnHit          func(target string, result SessionResult)
+	OnTargetStart  func(target string)
+	OnTargetDone   func(target string, summary TargetSummary)
+	OnError        func(target string, err error)
+	OnProgress     func(progress Progress)
+	OnLog          func(message string)
+	OnOriginResult func(result OriginResult)
+}
+
+type Progress struct {
+	Phase     string
+	Completed int
+	Total     int
+}
+
+type TargetSummary struct {
+	Alive       bool
+	Restored    bool
+	Interrupted bool
+	Hits        int
+}
+
+type ScanSummary struct {
+	Hits             int
+	StartedTargets   int
+	CompletedTargets int
+	Interrupted      bool
+}
+
+type Hit struct {
+	Target string
+	Result SessionResult
+}
+
+type hitSubscriber struct {
+	ctx  context.Context
+	hits chan Hit
+}
+
+func (s *Scanner) Hits(ctx context.Context) <-chan Hit {
+	subscriber := &hitSubscriber{ctx: ctx, hits: make(chan Hit, 100)}
+
+	s.hookMutex.Lock()
+	s.hitSubscribers = append(s.hitSubscribers, subscriber)
+	s.hookMutex.Unlock()
+
+	go func() {
+		defer close(subscriber.hits)
+		defer func() {
+			s.hookMutex.Lock()
+			defer s.hookMutex.Unlock()
+			s.hitSubscribers = slices.DeleteFunc(s.hitSubscribers, func(existing *hitSubscriber) bool {
+				return existing == subscriber
+			})
+		}()
+
+		s.Scan(ctx)
+	}()
+
+	return subscriber.hits
+}
+
+func (s *Scanner) hooks() Hooks {
+	s.hookMutex.Lock()
+	defer s.hookMutex.Unlock()
+
+	return s.Options.Hooks
+}
+
+func (s *Scanner) emitHit(target string, result SessionResult) {
+	s.hookMutex.Lock()
+	onHit := s.Options.Hooks.OnHit
+	subscribers := slices.Clone(s.hitSubscribers)
+	s.hookMutex.Unlock()
+
+	if onHit != nil {
+		onHit(target, result)
+	}
+
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber.hits <- Hit{Target: target, Result: result}:
+		case <-subscriber.ctx.Done():
+		}
+	}
+}
+
+func (s *Scanner) emitTargetStart(target string) {
+	if onTargetStart := s.hooks().OnTargetStart; onTargetStart != nil {
+		onTargetStart(target)
+	}
+}
+
+func (s *Scanner) emitTargetDone(target string, summary TargetSummary) {
+	if onTargetDone := s.hooks().OnTargetDone; onTargetDone != nil {
+		onTargetDone(target, summary)
+	}
+}
+
+func (s *Scanner) emitError(target string, err error) {
+	if onError := s.hooks().OnError; onError != nil {
+		onError(target, err)
+	}
+}
+
+func (s *Scanner) emitOriginResult(result OriginResult) {
+	if onOriginResult := s.hooks().OnOriginResult; onOriginResult != nil {
+		onOriginResult(result)
+	}
+}
+
+func (s *Scanner) startPhase(phase string, total int) {
+	s.hookMutex.Lock()
+	s.progress = Progress{Phase: phase, Total: total}
+	progress, onProgress := s.progress, s.Options.Hooks.OnProgress
+	s.hookMutex.Unlock()
+
+	if onProgress != nil {
+		onProgress(progress)
+	}
+}
+
+func (s *Scanner) UpdateProgress(count int) {
+	s.hookMutex.Lock()
+	s.progress.Completed += count
+	progress, onProgress := s.progress, s.Options.Hooks.OnProgress
+	s.hookMutex.Unlock()
+
+	if onProgress != nil {
+		onProgress(progress)
+	}
+}
+
+func (s *Scanner) Log(message string) {
+	if !s.Options.Verbose {
+		return
+	}
+
+	if onLog := s.hooks().OnLog; onLog != nil {
+		onLog(message)
+	}
+}
@@ -0,0 +1,191 @@
+package scanner
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>go-vhosts report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0; }
+.meta { color: #666; margin-bottom: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; cursor: pointer; user-select: none; white-space: nowrap; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafafa; }
+.status-2 { color: #1a7f37; } .status-3 { color: #9a6700; } .status-4, .status-5 { color: #cf222e; }
+.screenshot { width: 160px; height: 90px; border: 1px dashed #bbb; color: #999; font-size: 12px;
+  display: flex; align-items: center; justify-content: center; }
+code { font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>go-vhosts report</h1>
+<p class="meta">Generated {{.Generated}} &middot; {{len .Hits}} vhosts across {{len .Targets}} targets</p>
+
+<h2>Targets</h2>
+<table class="sortable">
+<thead><tr><th>Target</th><th data-type="number">VHosts</th></tr></thead>
+<tbody>
+{{range .Targets}}<tr><td>{{.Target}}</td><td>{{.Count}}</td></tr>
+{{end}}</tbody>
+</table>
+
+<h2>Virtual hosts</h2>
+<table class="sortable">
+<thead><tr>
+<th>Target</th><th>VHost</th><th>Path</th><th data-type="number">Status</th><th>Title</th>
+<th data-type="number">Length</th><th data-type="number">Words</th><th data-type="number">Lines</th>
+<th data-type="number">Time (ms)</th><th>Accessible</th><th>Protocol</th><th>Variant</th><th>Server</th><th>Technologies</th>
+<th>Content-Type</th><th>Location</th><th>IP</th><th>TLS Certificate</th><th>Fingerprints</th><th>Body Hash</th><th>Screenshot</th>
+</tr></thead>
+<tbody>
+{{range .Hits}}<tr>
+<td>{{.Target}}</td><td>{{.VHost}}</td><td>{{.Path}}</td>
+<td class="status-{{.StatusClass}}">{{.StatusCode}}</td><td>{{.Title}}</td>
+<td>{{.ContentLength}}</td><td>{{.Words}}</td><td>{{.Lines}}</td>
+<td>{{.ResponseTime}}</td><td>{{if .IsAccessible}}yes{{else}}no{{end}}</td>
+<td>{{.Protocol}}</td><td>{{.Variant}}</td><td>{{.Server}}</td><td>{{range .Technologies}}{{.}}<br>{{end}}</td>
+<td>{{.ContentType}}</td><td>{{.Location}}</td><td>{{.IP}}</td>
+<td>{{if .TLSSubject}}{{.TLSSubject}}<br><small>issued by {{.TLSIssuer}}</small>{{end}}</td>
+<td><code>{{.Fingerprint}}</code><br><code>{{.BodyFingerprint}}</code></td><td><code title="{{.BodyHash}}">{{.ShortBodyHash}}</code></td>
+<td><div class="screenshot" data-target="{{.Target}}" data-vhost="{{.VHost}}" data-path="{{.Path}}">no screenshot</div></td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, column) {
+    th.addEventListener("click", function () {
+      var ascending = !th.classList.contains("asc");
+      table.querySelectorAll("th").forEach(function (other) { other.classList.remove("asc", "desc"); });
+      th.classList.add(ascending ? "asc" : "desc");
+
+      var numeric = th.dataset.type === "number";
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[column].textContent.trim(), y = b.cells[column].textContent.trim();
+        var result = numeric ? parseFloat(x) - parseFloat(y) : x.localeCompare(y);
+        return ascending ? result : -result;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+type HTMLWriter struct {
+	path  string
+	mutex sync.Mutex
+	hits  []HitRecord
+	dirty bool
+}
+
+type htmlTarget struct {
+	Target string
+	Count  int
+}
+
+type htmlHit struct {
+	HitRecord
+	StatusClass   int
+	ShortBodyHash string
+}
+
+func NewHTMLWriter(path string) (*HTMLWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	file.Close()
+
+	return &HTMLWriter{path: path, dirty: true}, nil
+}
+
+func (w *HTMLWriter) WriteHit(target string, result SessionResult) error {
+	if !result.IsVHost {
+		return nil
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.hits = append(w.hits, HitRecord{Target: target, VHostResult: NewVHostResult(result)})
+	w.dirty = true
+	return nil
+}
+
+func (w *HTMLWriter) WriteResults(target string, results []SessionResult) error {
+	return w.Sync()
+}
+
+func (w *HTMLWriter) WriteOriginResult(result OriginResult) error {
+	return nil
+}
+
+func (w *HTMLWriter) Close() error {
+	return w.Sync()
+}
+
+func (w *HTMLWriter) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.dirty {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var hits []htmlHit
+	for _, hit := range w.hits {
+		counts[hit.Target]++
+		shortHash := hit.BodyHash
+		if len(shortHash) > 16 {
+			shortHash = shortHash[:16]
+		}
+		hits = append(hits, htmlHit{HitRecord: hit, StatusClass: hit.StatusCode / 100, ShortBodyHash: shortHash})
+	}
+
+	var targets []htmlTarget
+	for target, count := range counts {
+		targets = append(targets, htmlTarget{Target: target, Count: count})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Target < targets[j].Target
+	})
+
+	err := writeFileAtomic(w.path, func(file io.Writer) error {
+		err := htmlReportTemplate.Execute(file, map[string]any{
+			"Generated": time.Now().Format(time.RFC1123),
+			"Targets":   targets,
+			"Hits":      hits,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write HTML report: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.dirty = false
+	return nil
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestGetHTTP3TargetFromAltSvc(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		altSvc   string
+		expected string
+		ok       bool
+	}{
+		{"same host", "https://example.com/admin", `h3=":443"; ma=86400`, "https://example.com:443/admin", true},
+		{"other port", "https://example.com:8443", `h3=":9443"`, "https://example.com:9443", true},
+		{"other authority", "https://example.com", `h3="alt.example.com:443"`, "https://alt.example.com:443", true},
+		{"skips other protocols", "https://example.com", `h2=":443", h3-29=":443", h3=":8443"`, "https://example.com:8443", true},
+		{"no h3", "https://example.com", `h2=":443"`, "", false},
+		{"missing port", "https://example.com", `h3="example.com"`, "", false},
+		{"empty", "https://example.com", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, ok := GetHTTP3TargetFromAltSvc(test.target, test.altSvc)
+			if ok != test.ok || target != test.expected {
+				t.Errorf("GetHTTP3TargetFromAltSvc(%q, %q) = %q, %v; want %q, %v", test.target, test.altSvc, target, ok, test.expected, test.ok)
+			}
+		})
+	}
+}
+
+func startHTTP3Server(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	altSvcHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", fmt.Sprintf(`h3=":%d"; ma=60`, port))
+		handler.ServeHTTP(w, r)
+	})
+
+	tcpServer := httptest.NewUnstartedServer(altSvcHandler)
+	tcpServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tcpServer.StartTLS()
+	t.Cleanup(tcpServer.Close)
+
+	h3Server := &http3.Server{
+		Handler:   altSvcHandler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: tcpServer.TLS.Certificates}),
+	}
+	go h3Server.Serve(conn)
+	t.Cleanup(func() {
+		h3Server.Close()
+		conn.Close()
+	})
+
+	return tcpServer.URL
+}
+
+func TestHTTP3Probing(t *testing.T) {
+	target := startHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "secret.invalid" {
+			fmt.Fprintf(w, "<title>secret</title> admin panel served over %s", r.Proto)
+			return
+		}
+		fmt.Fprint(w, "<title>default</title> nothing to see")
+	}))
+
+	target = strings.Replace(target, "127.0.0.1", "localhost", 1)
+	scanner, err := NewScanner([]string{target}, []string{"secret.invalid", "www.invalid"}, ScannerOptions{HTTP3: true})
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	defer scanner.Close()
+
+	ctx := context.Background()
+	headers, err := scanner.requester.AliveCheck(ctx, target)
+	if err != nil {
+		t.Fatalf("AliveCheck: %v", err)
+	}
+
+	session := NewSession(scanner, target)
+	session.Results = nil
+	session.detectHTTP3(ctx, headers.Get("Alt-Svc"))
+	if session.HTTP3Target == "" {
+		t.Fatalf("HTTP/3 was not detected from Alt-Svc %q", headers.Get("Alt-Svc"))
+	}
+
+	results := session.Scan(ctx)
+	if len(results) != 1 {
+		t.Fatalf("got %d hits, want 1: %+v", len(results), results)
+	}
+
+	if results[0].VHost != "secret.invalid" {
+		t.Errorf("hit on %s, want secret.invalid", results[0].VHost)
+	}
+	if results[0].Response.Protocol != "HTTP/3.0" {
+		t.Errorf("hit protocol %s, want HTTP/3.0", results[0].Response.Protocol)
+	}
+	if results[0].Response.RemoteIP != "127.0.0.1" {
+		t.Errorf("hit remote IP %q, want 127.0.0.1 from the QUIC connection", results[0].Response.RemoteIP)
+	}
+}
@@ -0,0 +1,342 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ImportedTarget struct {
+	IP        string
+	Port      int
+	Scheme    string
+	Hostnames []string
+}
+
+var webPorts = map[int]string{
+	80:   "http",
+	81:   "http",
+	300:  "http",
+	443:  "https",
+	591:  "http",
+	593:  "http",
+	832:  "https",
+	981:  "https",
+	1010: "http",
+	1311: "https",
+	2082: "http",
+	2083: "https",
+	2087: "https",
+	2095: "http",
+	2096: "https",
+	3000: "http",
+	3128: "http",
+	4443: "https",
+	5000: "http",
+	5001: "https",
+	5601: "http",
+	7001: "http",
+	7002: "https",
+	8000: "http",
+	8008: "http",
+	8080: "http",
+	8081: "http",
+	8088: "http",
+	8443: "https",
+	8888: "http",
+	9000: "http",
+	9090: "http",
+	9443: "https",
+}
+
+type nmapRun struct {
+	Hosts []struct {
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Hostnames []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"hostnames>hostname"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+			Service struct {
+				Name   string `xml:"name,attr"`
+				Tunnel string `xml:"tunnel,attr"`
+			} `xml:"service"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+type masscanHost struct {
+	IP    string `json:"ip"`
+	Ports []struct {
+		Port   int    `json:"port"`
+		Proto  string `json:"proto"`
+		Status string `json:"status"`
+	} `json:"ports"`
+}
+
+type naabuResult struct {
+	Host     string          `json:"host"`
+	IP       string          `json:"ip"`
+	Port     json.RawMessage `json:"port"`
+	Protocol string          `json:"protocol"`
+	TLS      bool            `json:"tls"`
+}
+
+func LoadImportFile(path string, format string) ([]ImportedTarget, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	if format == "" || format == "auto" {
+		format = DetectImportFormat(content)
+	}
+
+	switch format {
+	case "nmap":
+		return ImportNmapXML(content)
+	case "masscan":
+		return ImportMasscan(content)
+	case "naabu":
+		return ImportNaabu(content)
+	}
+
+	return nil, fmt.Errorf("unknown import format %q (available: auto, nmap, masscan, naabu)", format)
+}
+
+func DetectImportFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return "nmap"
+	case bytes.HasPrefix(trimmed, []byte("[")),
+		bytes.HasPrefix(trimmed, []byte("#masscan")),
+		bytes.HasPrefix(trimmed, []byte("open ")),
+		bytes.Contains(trimmed, []byte(`"ports"`)):
+		return "masscan"
+	}
+
+	return "naabu"
+}
+
+func ImportNmapXML(content []byte) ([]ImportedTarget, error) {
+	var run nmapRun
+	if err := xml.Unmarshal(content, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse nmap XML: %w", err)
+	}
+
+	var imported []ImportedTarget
+	for _, host := range run.Hosts {
+		var ip string
+		for _, address := range host.Addresses {
+			if address.AddrType == "ipv4" || address.AddrType == "ipv6" {
+				ip = address.Addr
+				break
+			}
+		}
+		if ip == "" {
+			continue
+		}
+
+		var hostnames []string
+		for _, hostname := range host.Hostnames {
+			hostnames = appendHostname(hostnames, hostname.Name)
+		}
+
+		for _, port := range host.Ports {
+			if port.Protocol != "tcp" || port.State.State != "open" {
+				continue
+			}
+
+			scheme, ok := nmapServiceScheme(port.Service.Name, port.Service.Tunnel, port.PortID)
+			if !ok {
+				continue
+			}
+
+			imported = append(imported, ImportedTarget{
+				IP:        ip,
+				Port:      port.PortID,
+				Scheme:    scheme,
+				Hostnames: hostnames,
+			})
+		}
+	}
+
+	return imported, nil
+}
+
+func nmapServiceScheme(name string, tunnel string, port int) (string, bool) {
+	if name == "" {
+		scheme, ok := webPorts[port]
+		return scheme, ok
+	}
+
+	if !strings.Contains(name, "http") {
+		return "", false
+	}
+
+	if tunnel == "ssl" || strings.Contains(name, "https") {
+		return "https", true
+	}
+	return "http", true
+}
+
+func ImportMasscan(content []byte) ([]ImportedTarget, error) {
+	var imported []ImportedTarget
+
+	lines := bufio.NewScanner(bytes.NewReader(content))
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "[" || line == "]" || strings.HasPrefix(line, "{finished") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "{") {
+			var host masscanHost
+			if err := json.Unmarshal([]byte(strings.TrimSuffix(line, ",")), &host); err != nil {
+				return nil, fmt.Errorf("failed to parse masscan JSON line: %w", err)
+			}
+
+			for _, port := range host.Ports {
+				if port.Proto != "tcp" || (port.Status != "" && port.Status != "open") {
+					continue
+				}
+				if scheme, ok := webPorts[port.Port]; ok {
+					imported = append(imported, ImportedTarget{IP: host.IP, Port: port.Port, Scheme: scheme})
+				}
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "open" || fields[1] != "tcp" {
+			continue
+		}
+
+		port, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid masscan port %q", fields[2])
+		}
+		if scheme, ok := webPorts[port]; ok {
+			imported = append(imported, ImportedTarget{IP: fields[3], Port: port, Scheme: scheme})
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("error reading masscan output: %w", err)
+	}
+
+	return imported, nil
+}
+
+func ImportNaabu(content []byte) ([]ImportedTarget, error) {
+	var imported []ImportedTarget
+
+	lines := bufio.NewScanner(bytes.NewReader(content))
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+
+		var result naabuResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse naabu JSON line: %w", err)
+		}
+
+		port, tls, err := parseNaabuPort(result.Port)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Protocol != "" && result.Protocol != "tcp" {
+			continue
+		}
+
+		scheme, ok := webPorts[port]
+		if result.TLS || tls {
+			scheme, ok = "https", true
+		}
+		if !ok {
+			continue
+		}
+
+		ip := result.IP
+		if ip == "" {
+			ip = result.Host
+		}
+
+		var hostnames []string
+		if result.Host != ip {
+			hostnames = appendHostname(hostnames, result.Host)
+		}
+
+		imported = append(imported, ImportedTarget{IP: ip, Port: port, Scheme: scheme, Hostnames: hostnames})
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("error reading naabu output: %w", err)
+	}
+
+	return imported, nil
+}
+
+func parseNaabuPort(raw json.RawMessage) (int, bool, error) {
+	var port int
+	if err := json.Unmarshal(raw, &port); err == nil {
+		return port, false, nil
+	}
+
+	var detailed struct {
+		Port int  `json:"Port"`
+		TLS  bool `json:"TLS"`
+	}
+	if err := json.Unmarshal(raw, &detailed); err != nil {
+		return 0, false, fmt.Errorf("invalid naabu port %s", string(raw))
+	}
+
+	return detailed.Port, detailed.TLS, nil
+}
+
+func appendHostname(hostnames []string, hostname string) []string {
+	hostname = strings.TrimSuffix(strings.TrimSpace(hostname), ".")
+	if hostname == "" || slices.Contains(hostnames, hostname) {
+		return hostnames
+	}
+	return append(hostnames, hostname)
+}
+
+func ImportedTargetURLs(imported []ImportedTarget) []string {
+	var targets []string
+	for _, target := range imported {
+		url := FormatTargetURL(target.Scheme, target.IP, target.Port)
+		if !slices.Contains(targets, url) {
+			targets = append(targets, url)
+		}
+	}
+	return targets
+}
+
+func ImportedSeeds(imported []ImportedTarget) map[string][]string {
+	seeds := make(map[string][]string)
+	for _, target := range imported {
+		for _, hostname := range target.Hostnames {
+			seeds[target.IP] = appendHostname(seeds[target.IP], hostname)
+		}
+	}
+	return seeds
+}
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nmapSample = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE nmaprun>
+<nmaprun scanner="nmap" args="nmap -sV -oX scan.xml 192.0.2.0/24" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host starttime="1700000000" endtime="1700000010"><status state="up" reason="syn-ack" reason_ttl="0"/>
+<address addr="192.0.2.10" addrtype="ipv4"/>
+<address addr="00:11:22:33:44:55" addrtype="mac"/>
+<hostnames>
+<hostname name="www.example.com." type="user"/>
+<hostname name="www.example.com" type="PTR"/>
+</hostnames>
+<ports><extraports state="closed" count="995"><extrareasons reason="reset" count="995"/></extraports>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="ssh" product="OpenSSH" method="probed" conf="10"/></port>
+<port protocol="tcp" portid="80"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="http" product="nginx" method="probed" conf="10"/></port>
+<port protocol="tcp" portid="443"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="http" tunnel="ssl" product="nginx" method="probed" conf="10"/></port>
+<port protocol="tcp" portid="8080"><state state="closed" reason="reset" reason_ttl="64"/><service name="http-proxy" method="table" conf="3"/></port>
+<port protocol="tcp" portid="8443"><state state="filtered" reason="no-response" reason_ttl="0"/><service name="https-alt" method="table" conf="3"/></port>
+<port protocol="udp" portid="80"><state state="open" reason="udp-response" reason_ttl="64"/><service name="http" method="table" conf="3"/></port>
+</ports>
+</host>
+<host starttime="1700000000" endtime="1700000010"><status state="up" reason="echo-reply" reason_ttl="0"/>
+<address addr="2001:db8::5" addrtype="ipv6"/>
+<hostnames/>
+<ports>
+<port protocol="tcp" portid="9443"><state state="open" reason="syn-ack" reason_ttl="64"/></port>
+<port protocol="tcp" portid="8000"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="https-alt" method="probed" conf="10"/></port>
+</ports>
+</host>
+<runstats><finished time="1700000010" timestr="Tue Nov 14 22:13:30 2023" elapsed="10.00" exit="success"/><hosts up="2" down="254" total="256"/></runstats>
+</nmaprun>
+`
+
+const masscanJSONSample = `[
+{   "ip": "192.0.2.20",   "timestamp": "1700000000", "ports": [ {"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64} ] }
+,
+{   "ip": "192.0.2.21",   "timestamp": "1700000000", "ports": [ {"port": 22, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64} ] }
+,
+{   "ip": "192.0.2.22",   "timestamp": "1700000000", "ports": [ {"port": 8080, "proto": "tcp", "status": "closed", "reason": "rst", "ttl": 64} ] }
+,
+{   "ip": "192.0.2.23",   "timestamp": "1700000000", "ports": [ {"port": 80, "proto": "udp", "status": "open", "reason": "none", "ttl": 64} ] }
+]
+`
+
+const masscanListSample = `#masscan
+open tcp 8080 192.0.2.30 1700000000
+open tcp 22 192.0.2.30 1700000000
+open udp 80 192.0.2.31 1700000000
+open tcp 443 192.0.2.32 1700000001
+# end
+`
+
+const naabuSample = `{"host":"app.example.com","ip":"192.0.2.40","port":8443,"protocol":"tcp","tls":false,"timestamp":"2023-11-14T22:13:30Z"}
+{"host":"192.0.2.41","ip":"192.0.2.41","port":9999,"protocol":"tcp","tls":true,"timestamp":"2023-11-14T22:13:30Z"}
+{"ip":"192.0.2.42","port":{"Port":80,"Protocol":0,"TLS":false}}
+{"ip":"192.0.2.43","port":{"Port":7777,"Protocol":0,"TLS":true}}
+{"host":"db.example.com","ip":"192.0.2.44","port":5432,"protocol":"tcp","tls":false}
+{"host":"dns.example.com","ip":"192.0.2.45","port":80,"protocol":"udp","tls":false}
+`
+
+func TestImporters(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		sample string
+		parse  func([]byte) ([]ImportedTarget, error)
+		want   []ImportedTarget
+	}{
+		{"nmap", "nmap", nmapSample, ImportNmapXML, []ImportedTarget{
+			{IP: "192.0.2.10", Port: 80, Scheme: "http", Hostnames: []string{"www.example.com"}},
+			{IP: "192.0.2.10", Port: 443, Scheme: "https", Hostnames: []string{"www.example.com"}},
+			{IP: "2001:db8::5", Port: 9443, Scheme: "https"},
+			{IP: "2001:db8::5", Port: 8000, Scheme: "https"},
+		}},
+		{"masscan json", "masscan", masscanJSONSample, ImportMasscan, []ImportedTarget{
+			{IP: "192.0.2.20", Port: 443, Scheme: "https"},
+		}},
+		{"masscan list", "masscan", masscanListSample, ImportMasscan, []ImportedTarget{
+			{IP: "192.0.2.30", Port: 8080, Scheme: "http"},
+			{IP: "192.0.2.32", Port: 443, Scheme: "https"},
+		}},
+		{"naabu", "naabu", naabuSample, ImportNaabu, []ImportedTarget{
+			{IP: "192.0.2.40", Port: 8443, Scheme: "https", Hostnames: []string{"app.example.com"}},
+			{IP: "192.0.2.41", Port: 9999, Scheme: "https"},
+			{IP: "192.0.2.42", Port: 80, Scheme: "http"},
+			{IP: "192.0.2.43", Port: 7777, Scheme: "https"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if format := DetectImportFormat([]byte(test.sample)); format != test.format {
+				t.Errorf("DetectImportFormat = %s, want %s", format, test.format)
+			}
+
+			imported, err := test.parse([]byte(test.sample))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(imported, test.want) {
+				t.Errorf("imported:\n%+v\nwant:\n%+v", imported, test.want)
+			}
+		})
+	}
+}
+
+func TestImporterErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		parse  func([]byte) ([]ImportedTarget, error)
+		err    string
+	}{
+		{"nmap", "<nmaprun><host>", ImportNmapXML, "failed to parse nmap XML"},
+		{"masscan json", `{"ip": "192.0.2.1", "ports": [`, ImportMasscan, "failed to parse masscan JSON line"},
+		{"masscan list", "open tcp http 192.0.2.1 1700000000", ImportMasscan, `invalid masscan port "http"`},
+		{"naabu", `{"ip": "192.0.2.1", "port": "80"}`, ImportNaabu, `invalid naabu port "80"`},
+		{"naabu json", `not json`, ImportNaabu, "failed to parse naabu JSON line"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.parse([]byte(test.sample))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestImportedTargetURLsAndSeeds(t *testing.T) {
+	imported, err := ImportNmapXML([]byte(nmapSample))
+	if err != nil {
+		t.Fatalf("ImportNmapXML: %v", err)
+	}
+
+	urls := ImportedTargetURLs(imported)
+	want := []string{"http://192.0.2.10", "https://192.0.2.10", "https://[2001:db8::5]:9443", "https://[2001:db8::5]:8000"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("ImportedTargetURLs = %v, want %v", urls, want)
+	}
+
+	seeds := ImportedSeeds(imported)
+	if !reflect.DeepEqual(seeds, map[string][]string{"192.0.2.10": {"www.example.com"}}) {
+		t.Errorf("ImportedSeeds = %v", seeds)
+	}
+}
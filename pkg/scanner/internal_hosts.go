@@ -2,143 +2,77 @@ package scanner
 
 import (
 	"context"
-	"fmt"
-	"net"
-	"net/http"
 	"sync"
-	"time"
-
-	"github.com/schollz/progressbar/v3"
 )
 
-func (s *Scanner) RemoveNonInternalHosts() {
-	var internalHosts []string
-	var mutex sync.Mutex
-
-	fmt.Println("Filtering wordlist to only include internal hosts (not directly accessible)...")
-	bar := progressbar.NewOptions(len(s.Wordlist),
-		progressbar.OptionSetDescription("Filtering wordlist"),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "=",
-			SaucerHead:    ">",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionSetItsString("host"),
-		progressbar.OptionShowCount(),
-		progressbar.OptionSetPredictTime(true),
-		progressbar.OptionEnableColorCodes(true),
-	)
+func (s *Scanner) RemoveNonInternalHosts(ctx context.Context) (int, int, error) {
+	wordlist := s.Wordlist.All()
+	s.startPhase(PhaseFilter, len(wordlist))
+
+	internal := make([]bool, len(wordlist))
 
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, s.Options.ConcurrentVHosts)
 
-	for _, host := range s.Wordlist {
+	for index, host := range wordlist {
+		if !acquire(ctx, semaphore) {
+			break
+		}
+
 		wg.Add(1)
-		semaphore <- struct{}{}
 
-		go func(host string) {
+		go func(index int, host string) {
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			if !s.isVHostDirectlyAccessible(host) {
-				mutex.Lock()
-				internalHosts = append(internalHosts, host)
-				mutex.Unlock()
-			}
-			bar.Add(1)
-		}(host)
+			internal[index] = !s.isVHostDirectlyAccessible(ctx, host)
+			s.UpdateProgress(1)
+		}(index, host)
 	}
 
 	wg.Wait()
 
-	originalCount := len(s.Wordlist)
-	s.Wordlist = internalHosts
-
-	s.totalVHosts = len(s.Targets) * len(s.Wordlist)
-
-	fmt.Printf("\nFiltered wordlist from %d to %d internal hosts (%.1f%% reduction)\n",
-		originalCount,
-		len(internalHosts),
-		100.0-(float64(len(internalHosts))/float64(originalCount)*100.0))
-}
-
-func (s *Scanner) isVHostDirectlyAccessible(vhost string) bool {
-	s.cacheMutex.RLock()
-	result, exists := s.accessibilityCache[vhost]
-	s.cacheMutex.RUnlock()
-
-	if exists {
-		return result
+	if ctx.Err() != nil {
+		return len(wordlist), len(wordlist), ctx.Err()
 	}
 
-	ips, err := net.LookupHost(vhost)
-	if err != nil {
-		s.cacheMutex.Lock()
-		s.accessibilityCache[vhost] = false
-		s.cacheMutex.Unlock()
-		return false
+	var internalHosts []string
+	for index, host := range wordlist {
+		if internal[index] {
+			internalHosts = append(internalHosts, host)
+		}
 	}
 
-	for _, ip := range ips {
-		parsedIP := net.ParseIP(ip)
-		if parsedIP.IsLoopback() || parsedIP.IsPrivate() {
-			s.cacheMutex.Lock()
-			s.accessibilityCache[vhost] = false
-			s.cacheMutex.Unlock()
-			return false
+	if s.checkpointer != nil {
+		if err := s.checkpointer.SetScannedWordlist(internalHosts); err != nil {
+			return len(wordlist), len(internalHosts), err
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
-	defer cancel()
+	s.Wordlist = NewStaticList(internalHosts)
+	s.totalVHosts = s.countVHosts()
+
+	return len(wordlist), len(internalHosts), nil
+}
 
-	url := fmt.Sprintf("http://%s", vhost)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		s.cacheMutex.Lock()
-		s.accessibilityCache[vhost] = false
-		s.cacheMutex.Unlock()
+func (s *Scanner) isVHostDirectlyAccessible(ctx context.Context, vhost string) bool {
+	if ctx.Err() != nil {
 		return false
 	}
 
-	req.Header.Set("User-Agent", "go-vhosts/1.0")
+	s.cacheMutex.RLock()
+	result, exists := s.accessibilityCache[vhost]
+	s.cacheMutex.RUnlock()
 
-	resp, err := s.httpClient.Do(req)
-	if err == nil {
-		defer resp.Body.Close()
-		result := resp.StatusCode > 0
-		s.cacheMutex.Lock()
-		s.accessibilityCache[vhost] = result
-		s.cacheMutex.Unlock()
+	if exists {
 		return result
 	}
 
-	url = fmt.Sprintf("https://%s", vhost)
-	req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		s.cacheMutex.Lock()
-		s.accessibilityCache[vhost] = false
-		s.cacheMutex.Unlock()
+	result = s.requester.IsAccessible(ctx, vhost)
+	if ctx.Err() != nil {
 		return false
 	}
 
-	req.Header.Set("User-Agent", "go-vhosts/1.0")
-
-	resp, err = s.httpClient.Do(req)
-	if err != nil {
-		s.cacheMutex.Lock()
-		s.accessibilityCache[vhost] = false
-		s.cacheMutex.Unlock()
-		return false
-	}
-
-	if resp != nil && resp.Body != nil {
-		defer resp.Body.Close()
-	}
-
-	result = resp != nil && resp.StatusCode > 0
 	s.cacheMutex.Lock()
 	s.accessibilityCache[vhost] = result
 	s.cacheMutex.Unlock()
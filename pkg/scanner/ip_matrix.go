@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+)
+
+type IPMatrix struct {
+	mutex   sync.Mutex
+	entries map[string]map[matrixKey]*MatrixGroup
+}
+
+type matrixKey struct {
+	fingerprint string
+	served      bool
+}
+
+type MatrixGroup struct {
+	Fingerprint string   `json:"fingerprint"`
+	StatusCode  int      `json:"status_code"`
+	Title       string   `json:"title"`
+	Served      bool     `json:"served"`
+	Targets     []string `json:"targets"`
+}
+
+type MatrixEntry struct {
+	Host   string         `json:"host"`
+	Groups []*MatrixGroup `json:"groups"`
+}
+
+func NewIPMatrix() *IPMatrix {
+	return &IPMatrix{entries: make(map[string]map[matrixKey]*MatrixGroup)}
+}
+
+func (m *IPMatrix) Add(target string, vhost string, response *FullResponse, served bool) {
+	key := matrixKey{fingerprint: response.fingerprint(), served: served}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	groups, ok := m.entries[vhost]
+	if !ok {
+		groups = make(map[matrixKey]*MatrixGroup)
+		m.entries[vhost] = groups
+	}
+
+	group, ok := groups[key]
+	if !ok {
+		group = &MatrixGroup{
+			Fingerprint: key.fingerprint,
+			StatusCode:  response.StatusCode,
+			Title:       response.Title,
+			Served:      served,
+		}
+		groups[key] = group
+	}
+
+	if !slices.Contains(group.Targets, target) {
+		group.Targets = append(group.Targets, target)
+	}
+}
+
+func (m *IPMatrix) Entries() []MatrixEntry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var entries []MatrixEntry
+	for host, groups := range m.entries {
+		entry := MatrixEntry{Host: host}
+		for _, group := range groups {
+			sorted := *group
+			sorted.Targets = slices.Clone(group.Targets)
+			sort.Strings(sorted.Targets)
+			entry.Groups = append(entry.Groups, &sorted)
+		}
+
+		sort.Slice(entry.Groups, func(i, j int) bool {
+			if entry.Groups[i].Served != entry.Groups[j].Served {
+				return entry.Groups[i].Served
+			}
+			if len(entry.Groups[i].Targets) != len(entry.Groups[j].Targets) {
+				return len(entry.Groups[i].Targets) > len(entry.Groups[j].Targets)
+			}
+			return entry.Groups[i].Fingerprint < entry.Groups[j].Fingerprint
+		})
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Host < entries[j].Host
+	})
+
+	return entries
+}
+
+func (m *IPMatrix) WriteJSON(path string) error {
+	content, err := json.MarshalIndent(m.Entries(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal matrix: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write matrix file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MatchModeOr  = "or"
+	MatchModeAnd = "and"
+)
+
+type ResponseMatcher struct {
+	StatusCodes []NumberRange
+	Sizes       []NumberRange
+	Words       []NumberRange
+	Lines       []NumberRange
+	Regexp      *regexp.Regexp
+	Time        *TimeCondition
+	Mode        string
+}
+
+type NumberRange struct {
+	Min int64
+	Max int64
+}
+
+type TimeCondition struct {
+	Above bool
+	Limit time.Duration
+}
+
+type MatcherConfig struct {
+	StatusCodes string
+	Sizes       string
+	Words       string
+	Lines       string
+	Regexp      string
+	Time        string
+	Mode        string
+}
+
+func NewResponseMatcher(config MatcherConfig) (*ResponseMatcher, error) {
+	matcher := &ResponseMatcher{Mode: MatchModeOr}
+	if config.Mode != "" {
+		matcher.Mode = strings.ToLower(config.Mode)
+	}
+	if matcher.Mode != MatchModeOr && matcher.Mode != MatchModeAnd {
+		return nil, fmt.Errorf("invalid mode %q (available: %s, %s)", config.Mode, MatchModeOr, MatchModeAnd)
+	}
+
+	var err error
+	if config.StatusCodes != "all" {
+		if matcher.StatusCodes, err = ParseNumberRanges(config.StatusCodes); err != nil {
+			return nil, fmt.Errorf("invalid status codes: %w", err)
+		}
+	}
+	if matcher.Sizes, err = ParseNumberRanges(config.Sizes); err != nil {
+		return nil, fmt.Errorf("invalid sizes: %w", err)
+	}
+	if matcher.Words, err = ParseNumberRanges(config.Words); err != nil {
+		return nil, fmt.Errorf("invalid word counts: %w", err)
+	}
+	if matcher.Lines, err = ParseNumberRanges(config.Lines); err != nil {
+		return nil, fmt.Errorf("invalid line counts: %w", err)
+	}
+
+	if config.Regexp != "" {
+		if matcher.Regexp, err = regexp.Compile(config.Regexp); err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+	}
+
+	if config.Time != "" {
+		if matcher.Time, err = ParseTimeCondition(config.Time); err != nil {
+			return nil, err
+		}
+	}
+
+	if matcher.Empty() {
+		return nil, nil
+	}
+
+	return matcher, nil
+}
+
+func ParseNumberRanges(value string) ([]NumberRange, error) {
+	var ranges []NumberRange
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(part, "-")
+		if !isRange {
+			end = start
+		}
+
+		first, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", start)
+		}
+		last, err := strconv.ParseInt(strings.TrimSpace(end), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", end)
+		}
+		if first > last {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+
+		ranges = append(ranges, NumberRange{Min: first, Max: last})
+	}
+
+	return ranges, nil
+}
+
+func ParseTimeCondition(value string) (*TimeCondition, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || (value[0] != '>' && value[0] != '<') {
+		return nil, fmt.Errorf("invalid response time %q, expected >N or <N milliseconds", value)
+	}
+
+	milliseconds, err := strconv.ParseInt(strings.TrimSpace(value[1:]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response time %q, expected >N or <N milliseconds", value)
+	}
+
+	return &TimeCondition{Above: value[0] == '>', Limit: time.Duration(milliseconds) * time.Millisecond}, nil
+}
+
+func (m *ResponseMatcher) Empty() bool {
+	return len(m.StatusCodes) == 0 && len(m.Sizes) == 0 && len(m.Words) == 0 && len(m.Lines) == 0 &&
+		m.Regexp == nil && m.Time == nil
+}
+
+func (m *ResponseMatcher) Matches(response *FullResponse) bool {
+	var results []bool
+
+	if len(m.StatusCodes) > 0 {
+		results = append(results, inRanges(m.StatusCodes, int64(response.StatusCode)))
+	}
+	if len(m.Sizes) > 0 {
+		results = append(results, inRanges(m.Sizes, responseSize(response)))
+	}
+	if len(m.Words) > 0 {
+		results = append(results, inRanges(m.Words, int64(CountWords(response.Body))))
+	}
+	if len(m.Lines) > 0 {
+		results = append(results, inRanges(m.Lines, int64(CountLines(response.Body))))
+	}
+	if m.Regexp != nil {
+		results = append(results, m.Regexp.MatchString(responseText(response)))
+	}
+	if m.Time != nil {
+		if m.Time.Above {
+			results = append(results, response.Duration > m.Time.Limit)
+		} else {
+			results = append(results, response.Duration < m.Time.Limit)
+		}
+	}
+
+	if m.Mode == MatchModeAnd {
+		return !slices.Contains(results, false)
+	}
+	return slices.Contains(results, true)
+}
+
+func responseSize(response *FullResponse) int64 {
+	if response.Truncated {
+		return int64(response.ContentLength)
+	}
+	return int64(len(response.Body))
+}
+
+func (m *ResponseMatcher) countsBody() bool {
+	return m != nil && (len(m.Words) > 0 || len(m.Lines) > 0)
+}
+
+func inRanges(ranges []NumberRange, value int64) bool {
+	for _, r := range ranges {
+		if value >= r.Min && value <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func responseText(response *FullResponse) string {
+	var builder strings.Builder
+	for _, field := range headerFields(response.Headers) {
+		builder.WriteString(field.Name + ": " + field.Value + "\n")
+	}
+	builder.WriteString("\n")
+	builder.WriteString(response.Body)
+	return builder.String()
+}
+
+func (s *Scanner) shouldReport(response *FullResponse) bool {
+	if s.Options.Matcher != nil && !s.Options.Matcher.Matches(response) {
+		return false
+	}
+
+	if s.Options.Filter != nil && s.Options.Filter.Matches(response) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResponseMatcherSizes(t *testing.T) {
+	body := "<title>Admin</title> internal panel"
+
+	tests := []struct {
+		name     string
+		sizes    string
+		response FullResponse
+		want     bool
+	}{
+		{"body length", "35", FullResponse{Body: body, ContentLength: 35}, true},
+		{"content-length header ignored", "120", FullResponse{Body: body, ContentLength: 120}, false},
+		{"decompressed body", "35", FullResponse{Body: body, ContentLength: 20}, true},
+		{"empty body", "0", FullResponse{ContentLength: 512}, true},
+		{"truncated body uses content-length", "20000", FullResponse{Body: body, ContentLength: 20000, Truncated: true}, true},
+		{"truncated body prefix", "35", FullResponse{Body: body, ContentLength: 20000, Truncated: true}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := NewResponseMatcher(MatcherConfig{Sizes: test.sizes})
+			if err != nil {
+				t.Fatalf("NewResponseMatcher: %v", err)
+			}
+			if got := matcher.Matches(&test.response); got != test.want {
+				t.Errorf("Matches = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMinimalRejectsBodyCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher MatcherConfig
+		filter  MatcherConfig
+		err     bool
+	}{
+		{"sizes", MatcherConfig{Sizes: "100"}, MatcherConfig{Sizes: "0"}, false},
+		{"match words", MatcherConfig{Words: "10-20"}, MatcherConfig{}, true},
+		{"filter lines", MatcherConfig{StatusCodes: "200"}, MatcherConfig{Lines: "1"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := NewResponseMatcher(test.matcher)
+			if err != nil {
+				t.Fatalf("NewResponseMatcher: %v", err)
+			}
+			filter, err := NewResponseMatcher(test.filter)
+			if err != nil {
+				t.Fatalf("NewResponseMatcher: %v", err)
+			}
+
+			scanner, err := NewScanner(nil, nil, ScannerOptions{Minimal: true, Matcher: matcher, Filter: filter, Requester: newCannedRequester()})
+			if !test.err {
+				if err != nil {
+					t.Fatalf("NewScanner: %v", err)
+				}
+				scanner.Close()
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "cannot be combined with minimal mode") {
+				t.Errorf("NewScanner error = %v, want a minimal mode error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+type OriginCandidate struct {
+	Target        string  `json:"target"`
+	StatusCode    int     `json:"status_code"`
+	Title         string  `json:"title"`
+	ContentLength int     `json:"content_length"`
+	Similarity    float64 `json:"similarity"`
+	StatusMatch   bool    `json:"status_match"`
+	TitleMatch    bool    `json:"title_match"`
+	Score         float64 `json:"score"`
+}
+
+type OriginReference struct {
+	URL           string `json:"url"`
+	StatusCode    int    `json:"status_code"`
+	Title         string `json:"title"`
+	ContentLength int    `json:"content_length"`
+}
+
+type OriginResult struct {
+	Hostname   string            `json:"hostname"`
+	Reference  *OriginReference  `json:"reference"`
+	Candidates []OriginCandidate `json:"candidates"`
+}
+
+const RecordTypeOrigin = "origin"
+
+type OriginRecord struct {
+	Type   string       `json:"type"`
+	Origin OriginResult `json:"origin"`
+}
+
+func NewOriginRecord(result OriginResult) OriginRecord {
+	return OriginRecord{Type: RecordTypeOrigin, Origin: result}
+}
+
+func (s *Scanner) ScanOrigins(ctx context.Context) []OriginResult {
+	targets := s.aliveTargets(ctx)
+	hostnames := s.Wordlist.All()
+
+	candidates := make(map[string][]OriginCandidate)
+	var candidatesMutex sync.Mutex
+
+	references := s.fetchOriginReferences(ctx, targets, hostnames)
+
+	s.Log(fmt.Sprintf("Comparing %d hostnames against %d live targets", len(hostnames), len(targets)))
+	s.startPhase(PhaseOrigin, len(hostnames)*len(targets))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, s.Options.ConcurrentVHosts)
+
+	for _, hostname := range hostnames {
+		for _, target := range targets {
+			if !acquire(ctx, semaphore) {
+				break
+			}
+
+			wg.Add(1)
+
+			go func(hostname string, target string) {
+				defer wg.Done()
+				defer func() {
+					<-semaphore
+					s.UpdateProgress(1)
+				}()
+
+				reference, ok := references[GetOriginReferenceURL(target, hostname)]
+				if !ok {
+					return
+				}
+
+				response, err := s.requester.Probe(ctx, Probe{URL: target, VHost: hostname})
+				if err != nil {
+					return
+				}
+
+				candidate := CompareOrigin(target, *reference, *response, s.Options.Minimal)
+
+				candidatesMutex.Lock()
+				candidates[hostname] = append(candidates[hostname], candidate)
+				candidatesMutex.Unlock()
+			}(hostname, target)
+		}
+	}
+
+	wg.Wait()
+
+	var results []OriginResult
+	for _, hostname := range hostnames {
+		ranked := candidates[hostname]
+		sort.Slice(ranked, func(i, j int) bool {
+			return ranked[i].Score > ranked[j].Score
+		})
+
+		result := OriginResult{Hostname: hostname, Candidates: ranked}
+		for _, target := range targets {
+			referenceURL := GetOriginReferenceURL(target, hostname)
+			if reference, ok := references[referenceURL]; ok {
+				result.Reference = &OriginReference{
+					URL:           referenceURL,
+					StatusCode:    reference.StatusCode,
+					Title:         reference.Title,
+					ContentLength: reference.ContentLength,
+				}
+				break
+			}
+		}
+
+		s.emitOriginResult(result)
+		s.writeOriginResult(result)
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (s *Scanner) fetchOriginReferences(ctx context.Context, targets []string, hostnames []string) map[string]*FullResponse {
+	references := make(map[string]*FullResponse)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, s.Options.ConcurrentVHosts)
+
+	seen := make(map[string]bool)
+	for _, hostname := range hostnames {
+		for _, target := range targets {
+			referenceURL := GetOriginReferenceURL(target, hostname)
+			if seen[referenceURL] {
+				continue
+			}
+			seen[referenceURL] = true
+
+			if !acquire(ctx, semaphore) {
+				break
+			}
+
+			wg.Add(1)
+
+			go func(referenceURL string, hostname string) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+
+				response, err := s.requester.Probe(ctx, Probe{URL: referenceURL, VHost: hostname})
+				if err != nil {
+					s.Log(fmt.Sprintf("Failed to fetch live response for %s: %v", referenceURL, err))
+					return
+				}
+
+				response.fingerprint()
+
+				mutex.Lock()
+				references[referenceURL] = response
+				mutex.Unlock()
+			}(referenceURL, hostname)
+		}
+	}
+
+	wg.Wait()
+
+	return references
+}
+
+func (s *Scanner) aliveTargets(ctx context.Context) []string {
+	var alive []string
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, s.Options.Threads)
+
+	for _, target := range s.Targets.All() {
+		if !acquire(ctx, semaphore) {
+			break
+		}
+
+		wg.Add(1)
+
+		go func(target string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if _, err := s.requester.AliveCheck(ctx, target); err != nil {
+				s.Log(fmt.Sprintf("Target %s is not alive, skipping: %v", target, err))
+				return
+			}
+
+			mutex.Lock()
+			alive = append(alive, target)
+			mutex.Unlock()
+		}(target)
+	}
+
+	wg.Wait()
+	sort.Strings(alive)
+
+	return alive
+}
+
+func GetOriginReferenceURL(target string, hostname string) string {
+	parsedURL, err := url.Parse(target)
+	if err != nil {
+		return "https://" + hostname + "/"
+	}
+
+	return parsedURL.Scheme + "://" + hostname + parsedURL.RequestURI()
+}
+
+func CompareOrigin(target string, reference FullResponse, response FullResponse, minimal bool) OriginCandidate {
+	candidate := OriginCandidate{
+		Target:        target,
+		StatusCode:    response.StatusCode,
+		Title:         response.Title,
+		ContentLength: response.ContentLength,
+		StatusMatch:   response.StatusCode == reference.StatusCode,
+		TitleMatch:    response.Title != "" && response.Title == reference.Title,
+	}
+
+	if response.fingerprint() == reference.fingerprint() {
+		candidate.Similarity = 100
+	} else if !minimal {
+		candidate.Similarity = CalculateSimilarity(response.Body, reference.Body)
+	}
+
+	candidate.Score = candidate.Similarity * 0.6
+	if candidate.StatusMatch {
+		candidate.Score += 20
+	}
+	if candidate.TitleMatch {
+		candidate.Score += 20
+	}
+
+	return candidate
+}
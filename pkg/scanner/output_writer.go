@@ -1,26 +1,64 @@
 package scanner
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"sync"
+	"time"
 )
 
+type ResultWriter interface {
+	WriteHit(target string, result SessionResult) error
+	WriteResults(target string, results []SessionResult) error
+	WriteOriginResult(result OriginResult) error
+	Close() error
+}
+
+type SyncWriter interface {
+	Sync() error
+}
+
 type OutputWriter struct {
 	filePath      string
 	fileMutex     sync.Mutex
 	enabled       bool
-	file          *os.File
-	targetEntries map[string]bool
+	dirty         bool
+	targets       []string
+	targetEntries map[string][]VHostResult
+	origins       []OriginResult
+	originIndex   map[string]int
 }
 
 type VHostResult struct {
-	VHost         string `json:"vhost"`
-	StatusCode    int    `json:"status_code"`
-	Title         string `json:"title"`
-	ContentLength int64  `json:"content_length"`
-	IsAccessible  bool   `json:"is_accessible"`
+	VHost           string    `json:"vhost"`
+	Path            string    `json:"path"`
+	StatusCode      int       `json:"status_code"`
+	Title           string    `json:"title"`
+	ContentLength   int64     `json:"content_length"`
+	IsAccessible    bool      `json:"is_accessible"`
+	Protocol        string    `json:"protocol"`
+	Variant         string    `json:"variant"`
+	Fingerprint     string    `json:"fingerprint"`
+	ResponseTime    int64     `json:"response_time_ms"`
+	Server          string    `json:"server"`
+	Technologies    []string  `json:"technologies"`
+	ContentType     string    `json:"content_type"`
+	Location        string    `json:"location"`
+	BodyHash        string    `json:"body_hash"`
+	BodyFingerprint string    `json:"body_fingerprint"`
+	IP              string    `json:"ip"`
+	TLSSubject      string    `json:"tls_subject"`
+	TLSIssuer       string    `json:"tls_issuer"`
+	Words           int       `json:"words"`
+	Lines           int       `json:"lines"`
+	FirstSeen       time.Time `json:"first_seen,omitzero"`
+	LastSeen        time.Time `json:"last_seen,omitzero"`
 }
 
 type TargetResult struct {
@@ -28,89 +66,284 @@ type TargetResult struct {
 	VHosts []VHostResult `json:"vhosts"`
 }
 
-func NewOutputWriter(filePath string) (*OutputWriter, error) {
+func NewVHostResult(result SessionResult) VHostResult {
+	return VHostResult{
+		VHost:           result.VHost,
+		Path:            result.Path,
+		StatusCode:      result.Response.StatusCode,
+		Title:           result.Response.Title,
+		ContentLength:   int64(result.Response.ContentLength),
+		IsAccessible:    result.IsAccessible,
+		Protocol:        result.Response.Protocol,
+		Variant:         result.Response.Variant,
+		Fingerprint:     result.Response.Fingerprint,
+		ResponseTime:    result.Response.ResponseTime.Milliseconds(),
+		Server:          result.Response.Server,
+		Technologies:    result.Response.Technologies,
+		ContentType:     result.Response.ContentType,
+		Location:        result.Response.Location,
+		BodyHash:        result.Response.BodyHash,
+		BodyFingerprint: result.Response.BodyFingerprint,
+		IP:              result.Response.RemoteIP,
+		TLSSubject:      result.Response.TLSSubject,
+		TLSIssuer:       result.Response.TLSIssuer,
+		Words:           result.Response.Words,
+		Lines:           result.Response.Lines,
+	}
+}
+
+func (r VHostResult) key() string {
+	return r.VHost + "\x00" + r.Path + "\x00" + r.Variant
+}
+
+func (r VHostResult) SessionResult() SessionResult {
+	return SessionResult{
+		VHost: r.VHost,
+		Path:  r.Path,
+		Response: &SlimResponse{
+			StatusCode:      r.StatusCode,
+			Title:           r.Title,
+			ContentLength:   int(r.ContentLength),
+			Protocol:        r.Protocol,
+			Variant:         r.Variant,
+			Fingerprint:     r.Fingerprint,
+			ResponseTime:    time.Duration(r.ResponseTime) * time.Millisecond,
+			Server:          r.Server,
+			Technologies:    r.Technologies,
+			ContentType:     r.ContentType,
+			Location:        r.Location,
+			BodyHash:        r.BodyHash,
+			BodyFingerprint: r.BodyFingerprint,
+			RemoteIP:        r.IP,
+			TLSSubject:      r.TLSSubject,
+			TLSIssuer:       r.TLSIssuer,
+			Words:           r.Words,
+			Lines:           r.Lines,
+		},
+		IsVHost:      true,
+		IsAccessible: r.IsAccessible,
+	}
+}
+
+func NewOutputWriter(filePath string, appendMode bool) (*OutputWriter, error) {
 	if filePath == "" {
 		return &OutputWriter{enabled: false}, nil
 	}
 
+	writer := &OutputWriter{
+		filePath:      filePath,
+		enabled:       true,
+		targetEntries: make(map[string][]VHostResult),
+		originIndex:   make(map[string]int),
+	}
+
+	if appendMode {
+		if err := writer.load(); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	}
+
 	file, err := os.Create(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create output file: %w", err)
 	}
+	file.Close()
 
-	return &OutputWriter{
-		filePath:      filePath,
-		enabled:       true,
-		file:          file,
-		targetEntries: make(map[string]bool),
-	}, nil
+	return writer, nil
 }
 
-func (w *OutputWriter) WriteResults(target string, results []SessionResult) error {
-	if !w.enabled || len(results) == 0 {
+func (w *OutputWriter) load() error {
+	content, err := os.ReadFile(w.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		w.dirty = true
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
 
-	w.fileMutex.Lock()
-	defer w.fileMutex.Unlock()
+	targets, origins, err := ParseOutputFile(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to merge into %s: %w", w.filePath, err)
+	}
 
-	if w.file == nil {
-		return fmt.Errorf("output file is not open")
+	for _, target := range targets {
+		for _, entry := range target.VHosts {
+			w.merge(target.Target, entry)
+		}
+	}
+	for _, origin := range origins {
+		w.mergeOrigin(origin)
 	}
 
-	var vhostResults []VHostResult
-	for _, result := range results {
-		if !result.IsVHost {
+	return nil
+}
+
+func ParseOutputFile(reader io.Reader) ([]TargetResult, []OriginResult, error) {
+	var targets []TargetResult
+	var origins []OriginResult
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		content := bytes.TrimSpace(scanner.Bytes())
+		if len(content) == 0 {
 			continue
 		}
 
-		vhostResults = append(vhostResults, VHostResult{
-			VHost:         result.VHost,
-			StatusCode:    result.Response.StatusCode,
-			Title:         result.Response.Title,
-			ContentLength: int64(result.Response.ContentLength),
-			IsAccessible:  result.IsAccessible,
-		})
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if recordType, ok := fields["type"]; ok {
+			var record OriginRecord
+			if err := json.Unmarshal(content, &record); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if record.Type != RecordTypeOrigin {
+				return nil, nil, fmt.Errorf("line %d: unknown record type %s", line, recordType)
+			}
+			origins = append(origins, record.Origin)
+			continue
+		}
+
+		if _, ok := fields["vhost"]; ok {
+			var hit HitRecord
+			if err := json.Unmarshal(content, &hit); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+
+			index := slices.IndexFunc(targets, func(target TargetResult) bool {
+				return target.Target == hit.Target
+			})
+			if index < 0 {
+				targets = append(targets, TargetResult{Target: hit.Target})
+				index = len(targets) - 1
+			}
+			targets[index].VHosts = append(targets[index].VHosts, hit.VHostResult)
+			continue
+		}
+
+		var target TargetResult
+		if err := json.Unmarshal(content, &target); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		targets = append(targets, target)
 	}
 
-	if len(vhostResults) == 0 {
-		return nil
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	targetResult := TargetResult{
-		Target: target,
-		VHosts: vhostResults,
+	return targets, origins, nil
+}
+
+func (w *OutputWriter) merge(target string, entry VHostResult) {
+	entries, ok := w.targetEntries[target]
+	if !ok {
+		w.targets = append(w.targets, target)
 	}
 
-	resultJSON, err := json.Marshal(targetResult)
-	if err != nil {
-		return fmt.Errorf("failed to marshal target result: %w", err)
+	for i, existing := range entries {
+		if existing.key() != entry.key() {
+			continue
+		}
+
+		if !existing.FirstSeen.IsZero() && (entry.FirstSeen.IsZero() || existing.FirstSeen.Before(entry.FirstSeen)) {
+			entry.FirstSeen = existing.FirstSeen
+		}
+		if existing.LastSeen.After(entry.LastSeen) {
+			entry.LastSeen = existing.LastSeen
+		}
+		entries[i] = entry
+		return
 	}
 
-	if _, err := w.file.Write(resultJSON); err != nil {
-		return fmt.Errorf("failed to write target result: %w", err)
+	w.targetEntries[target] = append(entries, entry)
+}
+
+func (w *OutputWriter) WriteHit(target string, result SessionResult) error {
+	if !w.enabled || !result.IsVHost {
+		return nil
 	}
 
-	if _, err := w.file.WriteString("\n"); err != nil {
-		return fmt.Errorf("failed to write newline: %w", err)
+	entry := NewVHostResult(result)
+	entry.FirstSeen = time.Now().UTC()
+	entry.LastSeen = entry.FirstSeen
+
+	w.fileMutex.Lock()
+	defer w.fileMutex.Unlock()
+
+	w.merge(target, entry)
+	w.dirty = true
+
+	return nil
+}
+
+func (w *OutputWriter) WriteResults(target string, results []SessionResult) error {
+	return w.Sync()
+}
+
+func (w *OutputWriter) WriteOriginResult(result OriginResult) error {
+	if !w.enabled {
+		return nil
 	}
 
-	w.targetEntries[target] = true
+	w.fileMutex.Lock()
+	defer w.fileMutex.Unlock()
+
+	w.mergeOrigin(result)
+	w.dirty = true
 
-	return w.file.Sync()
+	return nil
 }
 
-func (w *OutputWriter) Close() error {
-	if !w.enabled || w.file == nil {
+func (w *OutputWriter) mergeOrigin(result OriginResult) {
+	if index, ok := w.originIndex[result.Hostname]; ok {
+		w.origins[index] = result
+		return
+	}
+
+	w.originIndex[result.Hostname] = len(w.origins)
+	w.origins = append(w.origins, result)
+}
+
+func (w *OutputWriter) Sync() error {
+	if !w.enabled {
 		return nil
 	}
 
 	w.fileMutex.Lock()
 	defer w.fileMutex.Unlock()
 
-	err := w.file.Close()
-	w.file = nil
-	w.targetEntries = nil
+	if !w.dirty {
+		return nil
+	}
+
+	err := writeFileAtomic(w.filePath, func(file io.Writer) error {
+		encoder := json.NewEncoder(file)
+		for _, target := range w.targets {
+			if err := encoder.Encode(TargetResult{Target: target, VHosts: w.targetEntries[target]}); err != nil {
+				return fmt.Errorf("failed to write target result: %w", err)
+			}
+		}
+		for _, origin := range w.origins {
+			if err := encoder.Encode(NewOriginRecord(origin)); err != nil {
+				return fmt.Errorf("failed to write origin result: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	w.dirty = false
+	return nil
+}
+
+func (w *OutputWriter) Close() error {
+	return w.Sync()
 }
@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeOutputFile(t *testing.T, lines ...any) string {
+	t.Helper()
+
+	var content strings.Builder
+	for _, line := range lines {
+		encoded, err := json.Marshal(line)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+		content.Write(encoded)
+		content.WriteByte('\n')
+	}
+
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestParseOutputFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		targets []TargetResult
+		origins []OriginResult
+		err     string
+	}{
+		{
+			name:    "target line",
+			content: `{"target":"https://10.0.0.1","vhosts":[{"vhost":"admin.example.com","path":"/","variant":"host"}]}`,
+			targets: []TargetResult{{Target: "https://10.0.0.1", VHosts: []VHostResult{{VHost: "admin.example.com", Path: "/", Variant: "host"}}}},
+		},
+		{
+			name: "hit lines grouped by target",
+			content: `{"target":"https://10.0.0.1","vhost":"admin.example.com","path":"/"}` + "\n\n" +
+				`{"target":"https://10.0.0.2","vhost":"api.example.com","path":"/"}` + "\n" +
+				`{"target":"https://10.0.0.1","vhost":"dev.example.com","path":"/"}`,
+			targets: []TargetResult{
+				{Target: "https://10.0.0.1", VHosts: []VHostResult{{VHost: "admin.example.com", Path: "/"}, {VHost: "dev.example.com", Path: "/"}}},
+				{Target: "https://10.0.0.2", VHosts: []VHostResult{{VHost: "api.example.com", Path: "/"}}},
+			},
+		},
+		{
+			name:    "origin record",
+			content: `{"type":"origin","origin":{"hostname":"www.example.com","reference":null,"candidates":[{"target":"https://10.0.0.1","similarity":98.5}]}}`,
+			origins: []OriginResult{{Hostname: "www.example.com", Candidates: []OriginCandidate{{Target: "https://10.0.0.1", Similarity: 98.5}}}},
+		},
+		{
+			name:    "unknown record type",
+			content: `{"target":"https://10.0.0.1","vhosts":[]}` + "\n" + `{"type":"screenshot"}`,
+			err:     `line 2: unknown record type "screenshot"`,
+		},
+		{
+			name:    "invalid line",
+			content: `{"target":`,
+			err:     "line 1: unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets, origins, err := ParseOutputFile(strings.NewReader(test.content))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("ParseOutputFile error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOutputFile: %v", err)
+			}
+
+			gotTargets, _ := json.Marshal(targets)
+			wantTargets, _ := json.Marshal(test.targets)
+			if string(gotTargets) != string(wantTargets) {
+				t.Errorf("targets = %s, want %s", gotTargets, wantTargets)
+			}
+			gotOrigins, _ := json.Marshal(origins)
+			wantOrigins, _ := json.Marshal(test.origins)
+			if string(gotOrigins) != string(wantOrigins) {
+				t.Errorf("origins = %s, want %s", gotOrigins, wantOrigins)
+			}
+		})
+	}
+}
+
+func TestOutputWriterAppendMerges(t *testing.T) {
+	firstSeen := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	existing := func(vhost string, path string, variant string, title string) VHostResult {
+		return VHostResult{VHost: vhost, Path: path, Variant: variant, Title: title, FirstSeen: firstSeen, LastSeen: firstSeen}
+	}
+
+	path := writeOutputFile(t,
+		TargetResult{Target: "https://10.0.0.1", VHosts: []VHostResult{
+			existing("admin.example.com", "/", VariantHost, "Old Admin"),
+			existing("admin.example.com", "/api", VariantHost, "API"),
+			existing("dev.example.com", "/", VariantHost, "Dev"),
+		}},
+		NewOriginRecord(OriginResult{Hostname: "www.example.com"}),
+	)
+
+	writer, err := NewOutputWriter(path, true)
+	if err != nil {
+		t.Fatalf("NewOutputWriter: %v", err)
+	}
+
+	started := time.Now().UTC()
+	hits := []VHostResult{
+		{VHost: "admin.example.com", Path: "/", Variant: VariantHost, Title: "New Admin"},
+		{VHost: "admin.example.com", Path: "/", Variant: VariantXForwardedHost, Title: "Admin via XFH"},
+	}
+	for _, hit := range hits {
+		if err := writer.WriteHit("https://10.0.0.1", hit.SessionResult()); err != nil {
+			t.Fatalf("WriteHit: %v", err)
+		}
+	}
+	if err := writer.WriteHit("https://10.0.0.2", hits[0].SessionResult()); err != nil {
+		t.Fatalf("WriteHit: %v", err)
+	}
+	if err := writer.WriteOriginResult(OriginResult{Hostname: "api.example.com"}); err != nil {
+		t.Fatalf("WriteOriginResult: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+
+	targets, origins, err := ParseOutputFile(file)
+	if err != nil {
+		t.Fatalf("ParseOutputFile: %v", err)
+	}
+
+	if len(targets) != 2 || targets[0].Target != "https://10.0.0.1" || targets[1].Target != "https://10.0.0.2" {
+		t.Fatalf("targets = %+v, want https://10.0.0.1 then https://10.0.0.2", targets)
+	}
+
+	tests := []struct {
+		vhost   string
+		path    string
+		variant string
+		title   string
+		merged  bool
+	}{
+		{"admin.example.com", "/", VariantHost, "New Admin", true},
+		{"admin.example.com", "/api", VariantHost, "API", false},
+		{"dev.example.com", "/", VariantHost, "Dev", false},
+		{"admin.example.com", "/", VariantXForwardedHost, "Admin via XFH", true},
+	}
+
+	entries := targets[0].VHosts
+	if len(entries) != len(tests) {
+		t.Fatalf("got %d entries for https://10.0.0.1, want %d: %+v", len(entries), len(tests), entries)
+	}
+	for i, test := range tests {
+		entry := entries[i]
+		if entry.VHost != test.vhost || entry.Path != test.path || entry.Variant != test.variant || entry.Title != test.title {
+			t.Errorf("entry %d = %s %s %s %q, want %s %s %s %q", i, entry.VHost, entry.Path, entry.Variant, entry.Title,
+				test.vhost, test.path, test.variant, test.title)
+		}
+
+		switch {
+		case !test.merged && (!entry.FirstSeen.Equal(firstSeen) || !entry.LastSeen.Equal(firstSeen)):
+			t.Errorf("entry %d was rewritten: first_seen %v, last_seen %v", i, entry.FirstSeen, entry.LastSeen)
+		case test.merged && test.variant == VariantHost && !entry.FirstSeen.Equal(firstSeen):
+			t.Errorf("entry %d first_seen = %v, want the original %v", i, entry.FirstSeen, firstSeen)
+		case test.merged && test.variant != VariantHost && entry.FirstSeen.Before(started):
+			t.Errorf("entry %d first_seen = %v, want the time of this scan", i, entry.FirstSeen)
+		case test.merged && entry.LastSeen.Before(started):
+			t.Errorf("entry %d last_seen = %v, want the time of this scan", i, entry.LastSeen)
+		}
+	}
+
+	if len(origins) != 2 || origins[0].Hostname != "www.example.com" || origins[1].Hostname != "api.example.com" {
+		t.Errorf("origins = %+v, want www.example.com and api.example.com", origins)
+	}
+}
+
+func TestAppendChecksHeader(t *testing.T) {
+	csvHeaderRow, err := csvRow(csvHeader)
+	if err != nil {
+		t.Fatalf("csvRow: %v", err)
+	}
+
+	tests := []struct {
+		format   string
+		existing string
+		err      string
+	}{
+		{"csv", "", ""},
+		{"csv", string(csvHeaderRow) + "https://10.0.0.1,admin.example.com\n", ""},
+		{"csv", "target,vhost,status\nhttps://10.0.0.1,admin.example.com,200\n", "header does not match the current columns"},
+		{"csv", "target", "header does not match the current columns"},
+		{"md", markdownHeader, ""},
+		{"md", "| Target | VHost | Status |\n|---|---|---|\n", "header does not match the current columns"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format+" "+test.existing, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results."+test.format)
+			if err := os.WriteFile(path, []byte(test.existing), 0644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+
+			writer, err := NewResultWriter(Output{Format: test.format, Path: path}, WriterOptions{Append: true})
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("NewResultWriter error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewResultWriter: %v", err)
+			}
+			writer.Close()
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	VariantXOriginalHost = "x-original-host"
+	VariantForwarded     = "forwarded"
+	VariantXRewriteURL   = "x-rewrite-url"
+)
+
+var OverrideModes = []string{
+	VariantXForwardedHost,
+	VariantXOriginalHost,
+	VariantForwarded,
+	VariantXRewriteURL,
+}
+
+func ParseOverrideModes(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if value == "all" {
+		return OverrideModes, nil
+	}
+
+	var modes []string
+	for _, mode := range strings.Split(value, ",") {
+		mode = strings.TrimSpace(mode)
+		if !slices.Contains(OverrideModes, mode) {
+			return nil, fmt.Errorf("unknown override mode %q (available: %s)", mode, strings.Join(OverrideModes, ", "))
+		}
+		modes = append(modes, mode)
+	}
+
+	return modes, nil
+}
+
+func OverrideHeader(variant string, parsedURL *url.URL, vhost string) (string, string, bool) {
+	switch variant {
+	case VariantXForwardedHost:
+		return "X-Forwarded-Host", vhost, true
+	case VariantXHost:
+		return "X-Host", vhost, true
+	case VariantXOriginalHost:
+		return "X-Original-Host", vhost, true
+	case VariantForwarded:
+		if strings.Contains(vhost, ":") {
+			return "Forwarded", fmt.Sprintf("host=%q", vhost), true
+		}
+		return "Forwarded", "host=" + vhost, true
+	case VariantXRewriteURL:
+		return "X-Rewrite-URL", "/" + vhost + parsedURL.RequestURI(), true
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	VariantHost           = "host"
+	VariantAbsoluteURI    = "absolute-uri"
+	VariantDuplicateHost  = "duplicate-host"
+	VariantXForwardedHost = "x-forwarded-host"
+	VariantXHost          = "x-host"
+	VariantTrailingDot    = "trailing-dot"
+	VariantPortSuffix     = "port-suffix"
+)
+
+var RawVariants = []string{
+	VariantHost,
+	VariantAbsoluteURI,
+	VariantDuplicateHost,
+	VariantXForwardedHost,
+	VariantXHost,
+	VariantTrailingDot,
+	VariantPortSuffix,
+}
+
+type RawRequester struct {
+	Scanner *Scanner
+	Variant string
+}
+
+func NewRawRequester(scanner *Scanner, variant string) *RawRequester {
+	return &RawRequester{Scanner: scanner, Variant: variant}
+}
+
+func ParseRawVariants(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if value == "all" {
+		return RawVariants, nil
+	}
+
+	var variants []string
+	for _, variant := range strings.Split(value, ",") {
+		variant = strings.TrimSpace(variant)
+		if !IsRawVariant(variant) {
+			return nil, fmt.Errorf("unknown raw variant %q (available: %s)", variant, strings.Join(RawVariants, ", "))
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants, nil
+}
+
+func IsRawVariant(variant string) bool {
+	return slices.Contains(RawVariants, variant)
+}
+
+func (r *RawRequester) RequestVHost(ctx context.Context, targetURL string, vhost string) (*FullResponse, error) {
+	r.Scanner.Log(fmt.Sprintf("Requesting %s with vhost %s (raw %s)", targetURL, vhost, r.Variant))
+
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := r.dial(ctx, parsedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := conn.SetDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return nil, err
+	}
+
+	request := r.buildRequest(parsedURL, vhost)
+	startedAt := time.Now()
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	fullResponse, err := readFullResponse(resp, r.Scanner.Options.Minimal)
+	if err != nil {
+		return nil, err
+	}
+	fullResponse.Variant = r.Variant
+	fullResponse.Request = describeRawRequest(request, targetURL)
+	fullResponse.StartedAt = startedAt
+	fullResponse.Duration = time.Since(startedAt)
+	fullResponse.RemoteIP = addressIP(conn.RemoteAddr())
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		fullResponse.Certificate = peerCertificate(&state)
+	}
+
+	return fullResponse, nil
+}
+
+func describeRawRequest(request []byte, targetURL string) *SentRequest {
+	lines := strings.Split(strings.TrimSuffix(string(request), "\r\n\r\n"), "\r\n")
+
+	sent := &SentRequest{Method: "GET", URL: targetURL, Proto: "HTTP/1.1", RequestLine: lines[0]}
+	for _, line := range lines[1:] {
+		name, value, ok := strings.Cut(line, ": ")
+		if ok {
+			sent.Headers = append(sent.Headers, HeaderField{Name: name, Value: value})
+		}
+	}
+
+	return sent
+}
+
+func (r *RawRequester) dial(ctx context.Context, parsedURL *url.URL) (net.Conn, error) {
+	address := net.JoinHostPort(parsedURL.Hostname(), GetPortFromURL(parsedURL))
+	dialer := &net.Dialer{Timeout: 7 * time.Second}
+
+	if parsedURL.Scheme != "https" {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	if net.ParseIP(parsedURL.Hostname()) == nil {
+		tlsConfig.ServerName = parsedURL.Hostname()
+	}
+
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+	return tlsDialer.DialContext(ctx, "tcp", address)
+}
+
+func (r *RawRequester) buildRequest(parsedURL *url.URL, vhost string) []byte {
+	path := parsedURL.RequestURI()
+	targetHost := parsedURL.Host
+
+	requestTarget := path
+	var hostHeaders []string
+
+	switch r.Variant {
+	case VariantAbsoluteURI:
+		requestTarget = parsedURL.Scheme + "://" + vhost + path
+		hostHeaders = []string{"Host: " + targetHost}
+	case VariantDuplicateHost:
+		hostHeaders = []string{"Host: " + targetHost, "Host: " + vhost}
+	case VariantTrailingDot:
+		hostHeaders = []string{"Host: " + strings.TrimSuffix(vhost, ".") + "."}
+	case VariantPortSuffix:
+		hostHeaders = []string{"Host: " + net.JoinHostPort(vhost, GetPortFromURL(parsedURL))}
+	default:
+		if name, value, ok := OverrideHeader(r.Variant, parsedURL, vhost); ok {
+			hostHeaders = []string{"Host: " + targetHost, name + ": " + value}
+		} else {
+			hostHeaders = []string{"Host: " + vhost}
+		}
+	}
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "GET %s HTTP/1.1\r\n", requestTarget)
+	for _, header := range hostHeaders {
+		request.WriteString(header + "\r\n")
+	}
+	request.WriteString("User-Agent: " + r.Scanner.Options.UserAgent + "\r\n")
+
+	accept := "*/*"
+	for _, header := range r.Scanner.Options.Headers {
+		switch header.Name {
+		case "Accept":
+			accept = header.Value
+		case "Connection":
+		default:
+			request.WriteString(header.Name + ": " + header.Value + "\r\n")
+		}
+	}
+	request.WriteString("Accept: " + accept + "\r\n")
+	request.WriteString("Connection: close\r\n\r\n")
+
+	return []byte(request.String())
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newRawTestScanner(t *testing.T) *Scanner {
+	t.Helper()
+
+	scanner, err := NewScanner(nil, nil, ScannerOptions{
+		UserAgent: "test-agent",
+		Headers: []HeaderField{
+			{Name: "cookie", Value: "session=abc"},
+			{Name: "Accept", Value: "text/html"},
+			{Name: "Connection", Value: "keep-alive"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	t.Cleanup(func() { scanner.Close() })
+
+	return scanner
+}
+
+func TestRawRequestBytes(t *testing.T) {
+	const trailer = "User-Agent: test-agent\r\nCookie: session=abc\r\nAccept: text/html\r\nConnection: close\r\n\r\n"
+
+	tests := []struct {
+		variant string
+		target  string
+		want    string
+	}{
+		{VariantHost, "https://10.0.0.1/login?next=/",
+			"GET /login?next=/ HTTP/1.1\r\nHost: admin.example.com\r\n"},
+		{VariantAbsoluteURI, "https://10.0.0.1:8443/login",
+			"GET https://admin.example.com/login HTTP/1.1\r\nHost: 10.0.0.1:8443\r\n"},
+		{VariantDuplicateHost, "http://10.0.0.1",
+			"GET / HTTP/1.1\r\nHost: 10.0.0.1\r\nHost: admin.example.com\r\n"},
+		{VariantXForwardedHost, "http://10.0.0.1:8080/",
+			"GET / HTTP/1.1\r\nHost: 10.0.0.1:8080\r\nX-Forwarded-Host: admin.example.com\r\n"},
+		{VariantXHost, "http://10.0.0.1/",
+			"GET / HTTP/1.1\r\nHost: 10.0.0.1\r\nX-Host: admin.example.com\r\n"},
+		{VariantTrailingDot, "https://10.0.0.1/",
+			"GET / HTTP/1.1\r\nHost: admin.example.com.\r\n"},
+		{VariantPortSuffix, "https://10.0.0.1/",
+			"GET / HTTP/1.1\r\nHost: admin.example.com:443\r\n"},
+		{VariantPortSuffix, "http://10.0.0.1:8080/",
+			"GET / HTTP/1.1\r\nHost: admin.example.com:8080\r\n"},
+	}
+
+	scanner := newRawTestScanner(t)
+	for _, test := range tests {
+		t.Run(test.variant+" "+test.target, func(t *testing.T) {
+			parsedURL, err := url.Parse(test.target)
+			if err != nil {
+				t.Fatalf("url.Parse: %v", err)
+			}
+
+			got := string(NewRawRequester(scanner, test.variant).buildRequest(parsedURL, "admin.example.com"))
+			if got != test.want+trailer {
+				t.Errorf("request bytes:\n%q\nwant:\n%q", got, test.want+trailer)
+			}
+		})
+	}
+}
+
+func TestRawRequesterWritesRequest(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var request strings.Builder
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			request.WriteString(line)
+			if err != nil || line == "\r\n" {
+				break
+			}
+		}
+		received <- request.String()
+
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 21\r\n\r\n<title>raw</title> ok")
+	}()
+
+	scanner := newRawTestScanner(t)
+	target := "http://" + listener.Addr().String() + "/admin"
+
+	response, err := NewRawRequester(scanner, VariantAbsoluteURI).RequestVHost(context.Background(), target, "secret.example.com")
+	if err != nil {
+		t.Fatalf("RequestVHost: %v", err)
+	}
+
+	want := "GET http://secret.example.com/admin HTTP/1.1\r\nHost: " + listener.Addr().String() + "\r\n" +
+		"User-Agent: test-agent\r\nCookie: session=abc\r\nAccept: text/html\r\nConnection: close\r\n\r\n"
+	if got := <-received; got != want {
+		t.Errorf("server received:\n%q\nwant:\n%q", got, want)
+	}
+
+	if response.Title != "raw" || response.Request.RequestLine != "GET http://secret.example.com/admin HTTP/1.1" {
+		t.Errorf("got title %q and request line %q", response.Title, response.Request.RequestLine)
+	}
+}
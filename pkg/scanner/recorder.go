@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	RecordProbe      = "probe"
+	RecordAlive      = "alive"
+	RecordAccessible = "accessible"
+
+	bodyEncodingBase64 = "base64"
+)
+
+type RecordedExchange struct {
+	Type         string        `json:"type"`
+	Time         time.Time     `json:"time"`
+	URL          string        `json:"url"`
+	VHost        string        `json:"vhost,omitempty"`
+	Variant      string        `json:"variant,omitempty"`
+	HTTP3        bool          `json:"http3,omitempty"`
+	Baseline     bool          `json:"baseline,omitempty"`
+	Response     *FullResponse `json:"response,omitempty"`
+	BodyEncoding string        `json:"body_encoding,omitempty"`
+	Headers      http.Header   `json:"headers,omitempty"`
+	Accessible   bool          `json:"accessible,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+type RecordingRequester struct {
+	Requester Requester
+
+	mutex  sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func NewRecordingRequester(requester Requester, path string) (*RecordingRequester, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record file: %w", err)
+	}
+
+	return &RecordingRequester{
+		Requester: requester,
+		file:      file,
+		writer:    bufio.NewWriter(file),
+	}, nil
+}
+
+func (r *RecordingRequester) Probe(ctx context.Context, probe Probe) (*FullResponse, error) {
+	response, err := r.Requester.Probe(ctx, probe)
+	if ctx.Err() != nil {
+		return response, err
+	}
+
+	exchange := RecordedExchange{
+		Type:     RecordProbe,
+		URL:      probe.URL,
+		VHost:    probe.VHost,
+		Variant:  probe.Variant,
+		HTTP3:    probe.HTTP3,
+		Baseline: probe.Baseline,
+		Response: response,
+	}
+	if response != nil && !utf8.ValidString(response.Body) {
+		encoded := *response
+		encoded.Body = base64.StdEncoding.EncodeToString([]byte(response.Body))
+		exchange.Response = &encoded
+		exchange.BodyEncoding = bodyEncodingBase64
+	}
+	r.record(exchange, err)
+
+	return response, err
+}
+
+func (r *RecordingRequester) AliveCheck(ctx context.Context, target string) (http.Header, error) {
+	headers, err := r.Requester.AliveCheck(ctx, target)
+	if ctx.Err() != nil {
+		return headers, err
+	}
+
+	r.record(RecordedExchange{Type: RecordAlive, URL: target, Headers: headers}, err)
+
+	return headers, err
+}
+
+func (r *RecordingRequester) IsAccessible(ctx context.Context, vhost string) bool {
+	accessible := r.Requester.IsAccessible(ctx, vhost)
+
+	if ctx.Err() == nil {
+		r.record(RecordedExchange{Type: RecordAccessible, VHost: vhost, Accessible: accessible}, nil)
+	}
+
+	return accessible
+}
+
+func (r *RecordingRequester) record(exchange RecordedExchange, err error) {
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	exchange.Time = time.Now()
+
+	line, marshalErr := json.Marshal(exchange)
+	if marshalErr != nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.writer.Write(line)
+	r.writer.WriteByte('\n')
+}
+
+func (r *RecordingRequester) Close() error {
+	r.mutex.Lock()
+	err := r.writer.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.mutex.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to write record file: %w", err)
+	}
+
+	if closer, ok := r.Requester.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
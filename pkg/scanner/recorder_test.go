@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func scanHits(t *testing.T, targets []string, wordlist []string, requester Requester, recordFile string) []Hit {
+	t.Helper()
+
+	scanner, err := NewScanner(targets, wordlist, ScannerOptions{Requester: requester, RecordFile: recordFile})
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+
+	var hits []Hit
+	for hit := range scanner.Hits(context.Background()) {
+		hits = append(hits, hit)
+	}
+
+	if err := scanner.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return hits
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	requester := newCannedRequester()
+	requester.pages["binary.example.com"] = "\x1f\x8b\x08\x00<title>Caf\xe9 Backup</title> \xff\xfe archive contents"
+
+	targets := []string{"https://example.com"}
+	wordlist := []string{"www.example.com", "admin.example.com", "binary.example.com"}
+	recordFile := filepath.Join(t.TempDir(), "probes.jsonl")
+
+	recorded := scanHits(t, targets, wordlist, requester, recordFile)
+
+	replay, err := LoadReplayRequester(recordFile)
+	if err != nil {
+		t.Fatalf("LoadReplayRequester: %v", err)
+	}
+	replayed := scanHits(t, replay.Targets(), wordlist, replay, "")
+
+	if len(recorded) != 2 || len(replayed) != len(recorded) {
+		t.Fatalf("recorded %d hits and replayed %d, want 2 each", len(recorded), len(replayed))
+	}
+
+	want := make(map[string]*SlimResponse)
+	for _, hit := range recorded {
+		want[hit.Result.VHost] = hit.Result.Response
+	}
+	for _, hit := range replayed {
+		if !reflect.DeepEqual(hit.Result.Response, want[hit.Result.VHost]) {
+			t.Errorf("%s: replayed response\n%+v\nwant:\n%+v", hit.Result.VHost, hit.Result.Response, want[hit.Result.VHost])
+		}
+	}
+
+	index := slices.IndexFunc(requester.sent(), func(probe Probe) bool { return probe.VHost == "binary.example.com" })
+	if index < 0 {
+		t.Fatalf("binary.example.com was never probed")
+	}
+	response, err := replay.Probe(context.Background(), requester.sent()[index])
+	if err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+	if body := requester.pages["binary.example.com"]; response.Body != body || response.Title != ExtractTitle(body) {
+		t.Errorf("replayed body %q with title %q, want %q", response.Body, response.Title, body)
+	}
+}
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+)
+
+type replayKey struct {
+	URL     string
+	VHost   string
+	Variant string
+	HTTP3   bool
+}
+
+type ReplayRequester struct {
+	mutex      sync.Mutex
+	probes     map[replayKey]RecordedExchange
+	baselines  map[replayKey][]RecordedExchange
+	served     map[replayKey]int
+	alive      map[string]RecordedExchange
+	accessible map[string]bool
+	targets    []string
+	vhosts     []string
+}
+
+func LoadReplayRequester(path string) (*ReplayRequester, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	defer file.Close()
+
+	requester := &ReplayRequester{
+		probes:     make(map[replayKey]RecordedExchange),
+		baselines:  make(map[replayKey][]RecordedExchange),
+		served:     make(map[replayKey]int),
+		alive:      make(map[string]RecordedExchange),
+		accessible: make(map[string]bool),
+	}
+
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for lines.Scan() {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+
+		var exchange RecordedExchange
+		if err := json.Unmarshal(lines.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("failed to parse record file: %w", err)
+		}
+		requester.add(exchange)
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("error reading record file: %w", err)
+	}
+
+	return requester, nil
+}
+
+func (r *ReplayRequester) add(exchange RecordedExchange) {
+	switch exchange.Type {
+	case RecordAlive:
+		r.alive[exchange.URL] = exchange
+		if !slices.Contains(r.targets, exchange.URL) {
+			r.targets = append(r.targets, exchange.URL)
+		}
+	case RecordAccessible:
+		r.accessible[exchange.VHost] = exchange.Accessible
+	case RecordProbe:
+		if exchange.Baseline {
+			key := replayKey{URL: exchange.URL, Variant: exchange.Variant, HTTP3: exchange.HTTP3}
+			r.baselines[key] = append(r.baselines[key], exchange)
+			return
+		}
+
+		key := replayKey{URL: exchange.URL, VHost: exchange.VHost, Variant: exchange.Variant, HTTP3: exchange.HTTP3}
+		r.probes[key] = exchange
+
+		if exchange.VHost != GetHostFromURL(exchange.URL) && !slices.Contains(r.vhosts, exchange.VHost) {
+			r.vhosts = append(r.vhosts, exchange.VHost)
+		}
+	}
+}
+
+func (r *ReplayRequester) Targets() []string {
+	return slices.Clone(r.targets)
+}
+
+func (r *ReplayRequester) VHosts() []string {
+	return slices.Clone(r.vhosts)
+}
+
+func (r *ReplayRequester) Probe(ctx context.Context, probe Probe) (*FullResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if probe.Baseline {
+		key := replayKey{URL: probe.URL, Variant: probe.Variant, HTTP3: probe.HTTP3}
+
+		r.mutex.Lock()
+		recorded := r.baselines[key]
+		if len(recorded) == 0 {
+			r.mutex.Unlock()
+			return nil, fmt.Errorf("no recorded baseline for %s (%s)", probe.URL, probe.Variant)
+		}
+		exchange := recorded[r.served[key]%len(recorded)]
+		r.served[key]++
+		r.mutex.Unlock()
+
+		return replayResponse(exchange)
+	}
+
+	exchange, ok := r.probes[replayKey{URL: probe.URL, VHost: probe.VHost, Variant: probe.Variant, HTTP3: probe.HTTP3}]
+	if !ok {
+		return nil, fmt.Errorf("no recorded response for %s with vhost %s (%s)", probe.URL, probe.VHost, probe.Variant)
+	}
+
+	return replayResponse(exchange)
+}
+
+func (r *ReplayRequester) AliveCheck(ctx context.Context, target string) (http.Header, error) {
+	exchange, ok := r.alive[target]
+	if !ok {
+		return nil, fmt.Errorf("no recorded alive check for %s", target)
+	}
+
+	if exchange.Error != "" {
+		return nil, errors.New(exchange.Error)
+	}
+
+	return exchange.Headers, nil
+}
+
+func (r *ReplayRequester) IsAccessible(ctx context.Context, vhost string) bool {
+	return r.accessible[vhost]
+}
+
+func replayResponse(exchange RecordedExchange) (*FullResponse, error) {
+	if exchange.Error != "" {
+		return nil, errors.New(exchange.Error)
+	}
+
+	if exchange.Response == nil {
+		return nil, fmt.Errorf("recorded exchange for %s has no response", exchange.URL)
+	}
+
+	response := *exchange.Response
+	if exchange.BodyEncoding == bodyEncodingBase64 {
+		body, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded body for %s: %w", exchange.URL, err)
+		}
+		response.Body = string(body)
+		response.Title = ExtractTitle(response.Body)
+	}
+	return &response, nil
+}
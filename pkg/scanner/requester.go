@@ -1,38 +1,391 @@
 package scanner
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/quic-go/quic-go/http3"
 )
 
 type FullResponse struct {
-	Body          string
-	Title         string
-	StatusCode    int
-	ContentLength int
+	Body          string        `json:"body"`
+	Title         string        `json:"title"`
+	StatusCode    int           `json:"status_code"`
+	ContentLength int           `json:"content_length"`
+	Protocol      string        `json:"protocol"`
+	Variant       string        `json:"variant"`
+	Fingerprint   string        `json:"fingerprint,omitempty"`
+	Headers       http.Header   `json:"headers,omitempty"`
+	Request       *SentRequest  `json:"request,omitempty"`
+	StartedAt     time.Time     `json:"started_at"`
+	Duration      time.Duration `json:"duration"`
+	RemoteIP      string        `json:"remote_ip,omitempty"`
+	Certificate   *Certificate  `json:"certificate,omitempty"`
+	Truncated     bool          `json:"truncated,omitempty"`
+}
+
+type Certificate struct {
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer"`
+}
+
+type SentRequest struct {
+	Method      string        `json:"method"`
+	URL         string        `json:"url"`
+	Proto       string        `json:"proto"`
+	RequestLine string        `json:"request_line"`
+	Headers     []HeaderField `json:"headers"`
+}
+
+type HeaderField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type SlimResponse struct {
-	Title         string
-	StatusCode    int
-	ContentLength int
+	Title           string
+	StatusCode      int
+	ContentLength   int
+	Protocol        string
+	Variant         string
+	Fingerprint     string
+	ResponseTime    time.Duration
+	Server          string
+	Technologies    []string
+	ContentType     string
+	Location        string
+	BodyHash        string
+	BodyFingerprint string
+	RemoteIP        string
+	TLSSubject      string
+	TLSIssuer       string
+	Words           int
+	Lines           int
+}
+
+var technologyHeaders = []string{
+	"X-Powered-By",
+	"X-AspNet-Version",
+	"X-AspNetMvc-Version",
+	"X-Generator",
+	"X-Runtime",
+	"X-Drupal-Cache",
+	"X-Varnish",
+	"Via",
+}
+
+func NewSlimResponse(response *FullResponse) *SlimResponse {
+	slim := &SlimResponse{
+		Title:         response.Title,
+		StatusCode:    response.StatusCode,
+		ContentLength: response.ContentLength,
+		Protocol:      response.Protocol,
+		Variant:       response.Variant,
+		Fingerprint:   response.fingerprint(),
+		ResponseTime:  response.Duration,
+		Server:        response.Headers.Get("Server"),
+		ContentType:   response.Headers.Get("Content-Type"),
+		Location:      response.Headers.Get("Location"),
+		RemoteIP:      response.RemoteIP,
+		Technologies:  []string{},
+	}
+
+	if !response.Truncated {
+		slim.BodyHash = BodyHash(response.Body)
+		slim.BodyFingerprint = BodyFingerprint(response.Body)
+		slim.Words = CountWords(response.Body)
+		slim.Lines = CountLines(response.Body)
+	}
+
+	for _, name := range technologyHeaders {
+		for _, value := range response.Headers.Values(name) {
+			slim.Technologies = append(slim.Technologies, name+": "+value)
+		}
+	}
+
+	if response.Certificate != nil {
+		slim.TLSSubject = response.Certificate.Subject
+		slim.TLSIssuer = response.Certificate.Issuer
+	}
+
+	return slim
+}
+
+func (r *FullResponse) fingerprint() string {
+	if r.Fingerprint == "" {
+		r.Fingerprint = ResponseFingerprint(r.StatusCode, r.Title, r.Body)
+	}
+	return r.Fingerprint
+}
+
+type Probe struct {
+	URL      string
+	VHost    string
+	Variant  string
+	HTTP3    bool
+	Baseline bool
+}
+
+type Requester interface {
+	Probe(ctx context.Context, probe Probe) (*FullResponse, error)
+	AliveCheck(ctx context.Context, target string) (http.Header, error)
+	IsAccessible(ctx context.Context, vhost string) bool
+}
+
+type VariantRequester interface {
+	RequestVHost(ctx context.Context, url string, vhost string) (*FullResponse, error)
+}
+
+const DefaultUserAgent = "go-vhosts/1.0"
+
+func ParseHeader(value string) (HeaderField, error) {
+	name, headerValue, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t\r\n") || strings.ContainsAny(headerValue, "\r\n") {
+		return HeaderField{}, fmt.Errorf("invalid header %q, expected \"Name: Value\"", value)
+	}
+
+	if strings.EqualFold(name, "Host") {
+		return HeaderField{}, fmt.Errorf("the Host header is set by the scanner and cannot be overridden")
+	}
+
+	return HeaderField{Name: http.CanonicalHeaderKey(name), Value: strings.TrimSpace(headerValue)}, nil
+}
+
+func ParseProxy(value string) (*url.URL, error) {
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", value)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxyURL, nil
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q (available: http, https, socks5, socks5h)", proxyURL.Scheme)
+}
+
+type rateLimiter struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mutex.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type NetworkRequester struct {
+	Scanner     *Scanner
+	httpClient  *http.Client
+	http3Client *http.Client
+	plain       *HTTPRequester
+	variants    map[string]VariantRequester
+	limiter     *rateLimiter
 }
 
-type Requester struct {
-	Scanner *Scanner
+func NewNetworkRequester(scanner *Scanner) *NetworkRequester {
+	requester := &NetworkRequester{
+		Scanner:    scanner,
+		httpClient: newHTTPClient(scanner.proxyURL),
+		variants:   make(map[string]VariantRequester),
+		limiter:    newRateLimiter(scanner.Options.RateLimit),
+	}
+
+	if scanner.Options.HTTP3 {
+		requester.http3Client = newHTTP3Client()
+	}
+
+	requester.plain = NewHTTPRequester(scanner, VariantHost, requester.httpClient, requester.http3Client)
+	for _, variant := range scanner.Variants() {
+		if scanner.usesRawRequester() {
+			requester.variants[variant] = NewRawRequester(scanner, variant)
+		} else {
+			requester.variants[variant] = NewHTTPRequester(scanner, variant, requester.httpClient, requester.http3Client)
+		}
+	}
+
+	return requester
 }
 
-func NewRequester(scanner *Scanner) *Requester {
-	return &Requester{Scanner: scanner}
+func (r *NetworkRequester) Probe(ctx context.Context, probe Probe) (*FullResponse, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	var requester VariantRequester = r.plain
+	if probe.Variant != "" {
+		var ok bool
+		requester, ok = r.variants[probe.Variant]
+		if !ok {
+			return nil, fmt.Errorf("no requester for variant %s", probe.Variant)
+		}
+	}
+
+	if probe.HTTP3 {
+		httpRequester, ok := requester.(*HTTPRequester)
+		if !ok {
+			return nil, fmt.Errorf("variant %s cannot be sent over HTTP/3", probe.Variant)
+		}
+		return httpRequester.RequestVHostHTTP3(ctx, probe.URL, probe.VHost)
+	}
+
+	return requester.RequestVHost(ctx, probe.URL, probe.VHost)
 }
 
-func (r *Requester) newHTTPClient() *http.Client {
+func (r *NetworkRequester) AliveCheck(ctx context.Context, target string) (http.Header, error) {
+	parsedURL, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, parsedURL.Hostname()); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: 3 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(parsedURL.Hostname(), GetPortFromURL(parsedURL)))
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Scanner.setRequestHeaders(req)
+	req.Header.Set("Connection", "close")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode <= 0 {
+		return nil, fmt.Errorf("invalid status code %d", resp.StatusCode)
+	}
+
+	return resp.Header, nil
+}
+
+func (r *NetworkRequester) IsAccessible(ctx context.Context, vhost string) bool {
+	ips, err := net.DefaultResolver.LookupHost(ctx, vhost)
+	if err != nil {
+		return false
+	}
+
+	for _, ip := range ips {
+		parsedIP := net.ParseIP(ip)
+		if parsedIP.IsLoopback() || parsedIP.IsPrivate() {
+			return false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+
+	for _, scheme := range []string{"http", "https"} {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return false
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s://%s", scheme, vhost), nil)
+		if err != nil {
+			return false
+		}
+
+		r.Scanner.setRequestHeaders(req)
+
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode > 0
+	}
+
+	return false
+}
+
+func (r *NetworkRequester) Close() error {
+	if r.http3Client != nil {
+		if transport, ok := r.http3Client.Transport.(*http3.Transport); ok {
+			return transport.Close()
+		}
+	}
+	return nil
+}
+
+type HTTPRequester struct {
+	Scanner     *Scanner
+	Variant     string
+	Client      *http.Client
+	HTTP3Client *http.Client
+}
+
+func NewHTTPRequester(scanner *Scanner, variant string, client *http.Client, http3Client *http.Client) *HTTPRequester {
+	return &HTTPRequester{Scanner: scanner, Variant: variant, Client: client, HTTP3Client: http3Client}
+}
+
+func newHTTPClient(proxyURL *url.URL) *http.Client {
+	var proxy func(*http.Request) (*url.URL, error)
+	if proxyURL != nil {
+		proxy = http.ProxyURL(proxyURL)
+	}
+
 	return &http.Client{
 		Timeout: 10 * time.Second,
 		Transport: &http.Transport{
+			Proxy: proxy,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: true,
 			},
@@ -47,57 +400,180 @@ func (r *Requester) newHTTPClient() *http.Client {
 	}
 }
 
-func (r *Requester) RequestVHost(url string, vhost string) (*FullResponse, error) {
-	r.Scanner.Log(fmt.Sprintf("Requesting %s with vhost %s", url, vhost))
+func newHTTP3Client() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http3.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (r *HTTPRequester) RequestVHost(ctx context.Context, url string, vhost string) (*FullResponse, error) {
+	return r.requestVHost(ctx, r.Client, url, vhost)
+}
+
+func (r *HTTPRequester) RequestVHostHTTP3(ctx context.Context, url string, vhost string) (*FullResponse, error) {
+	if r.HTTP3Client == nil {
+		return nil, fmt.Errorf("HTTP/3 is not enabled")
+	}
+
+	return r.requestVHost(ctx, r.HTTP3Client, url, vhost)
+}
+
+func (r *HTTPRequester) requestVHost(ctx context.Context, client *http.Client, url string, vhost string) (*FullResponse, error) {
+	r.Scanner.Log(fmt.Sprintf("Requesting %s with vhost %s (%s)", url, vhost, r.Variant))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Host = vhost
-	req.Header.Set("User-Agent", "go-vhosts/1.0")
+	r.Scanner.setRequestHeaders(req)
+	if name, value, ok := OverrideHeader(r.Variant, req.URL, vhost); ok {
+		req.Header.Set(name, value)
+	} else {
+		req.Host = vhost
+	}
 	req.Header.Set("Connection", "close")
 
-	resp, err := r.Scanner.httpClient.Do(req)
+	var remoteIP string
+	var traceMutex sync.Mutex
+	var wroteHeaders []HeaderField
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			traceMutex.Lock()
+			wroteHeaders = nil
+			traceMutex.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			remoteIP = addressIP(info.Conn.RemoteAddr())
+		},
+		WroteHeaderField: func(key string, value []string) {
+			traceMutex.Lock()
+			defer traceMutex.Unlock()
+			for _, value := range value {
+				wroteHeaders = append(wroteHeaders, HeaderField{Name: key, Value: value})
+			}
+		},
+	}))
+
+	startedAt := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var bodyString string
-	var bodyBytes []byte
-	var contentLength int
+	fullResponse, err := readFullResponse(resp, r.Scanner.Options.Minimal)
+	if err != nil {
+		return nil, err
+	}
+	fullResponse.Variant = r.Variant
+	traceMutex.Lock()
+	fullResponse.Request = describeRequest(req, resp, wroteHeaders)
+	traceMutex.Unlock()
+	fullResponse.StartedAt = startedAt
+	fullResponse.Duration = time.Since(startedAt)
+	fullResponse.RemoteIP = remoteIP
+	if remoteIP == "" && net.ParseIP(req.URL.Hostname()) != nil {
+		fullResponse.RemoteIP = req.URL.Hostname()
+	}
+	fullResponse.Certificate = peerCertificate(resp.TLS)
 
-	if r.Scanner.Options.Minimal {
-		bodyBytes = make([]byte, 8192)
-		n, _ := resp.Body.Read(bodyBytes)
-		bodyBytes = bodyBytes[:n]
-		bodyString = string(bodyBytes)
+	return fullResponse, nil
+}
 
-		if resp.ContentLength > 0 {
-			contentLength = int(resp.ContentLength)
-		} else {
-			contentLength = n
+func describeRequest(req *http.Request, resp *http.Response, wroteHeaders []HeaderField) *SentRequest {
+	proto := req.Proto
+	if resp.ProtoMajor > 1 {
+		proto = resp.Proto
+	}
+
+	sent := &SentRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Proto:       proto,
+		RequestLine: fmt.Sprintf("%s %s %s", req.Method, req.URL.RequestURI(), proto),
+		Headers:     []HeaderField{{Name: "Host", Value: req.Host}},
+	}
+	if len(wroteHeaders) > 0 {
+		sent.Headers = wroteHeaders
+		return sent
+	}
+	if req.Host == "" {
+		sent.Headers[0].Value = req.URL.Host
+	}
+	sent.Headers = append(sent.Headers, headerFields(req.Header)...)
+
+	return sent
+}
+
+func addressIP(address net.Addr) string {
+	if address == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(address.String())
+	if err != nil {
+		return address.String()
+	}
+	return host
+}
+
+func peerCertificate(state *tls.ConnectionState) *Certificate {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	certificate := state.PeerCertificates[0]
+	return &Certificate{
+		Subject: certificate.Subject.String(),
+		Issuer:  certificate.Issuer.String(),
+	}
+}
+
+const minimalBodyLimit = 8192
+
+func readFullResponse(resp *http.Response, minimal bool) (*FullResponse, error) {
+	var bodyBytes []byte
+	var err error
+	truncated := false
+
+	if minimal {
+		bodyBytes, err = io.ReadAll(io.LimitReader(resp.Body, minimalBodyLimit+1))
+		if err != nil && len(bodyBytes) == 0 {
+			return nil, err
+		}
+		if len(bodyBytes) > minimalBodyLimit {
+			bodyBytes = bodyBytes[:minimalBodyLimit]
+			truncated = true
 		}
 	} else {
 		bodyBytes, err = io.ReadAll(resp.Body)
 		if err != nil {
 			return nil, err
 		}
-		bodyString = string(bodyBytes)
-		contentLength = len(bodyBytes)
-		if resp.ContentLength > 0 {
-			contentLength = int(resp.ContentLength)
-		}
 	}
 
-	title := ExtractTitle(bodyString)
+	bodyString := string(bodyBytes)
+	contentLength := len(bodyBytes)
+	if resp.ContentLength > 0 {
+		contentLength = int(resp.ContentLength)
+	}
 
 	return &FullResponse{
 		Body:          bodyString,
-		Title:         title,
+		Title:         ExtractTitle(bodyString),
 		StatusCode:    resp.StatusCode,
 		ContentLength: contentLength,
+		Protocol:      resp.Proto,
+		Headers:       resp.Header,
+		Truncated:     truncated,
 	}, nil
 }
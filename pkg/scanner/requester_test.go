@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHTTPRequesterRecordsSentHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<title>ok</title>"))
+	}))
+	defer server.Close()
+
+	scanner := newRawTestScanner(t)
+	client := newHTTPClient(nil)
+
+	tests := []struct {
+		variant string
+		want    []HeaderField
+	}{
+		{VariantHost, []HeaderField{
+			{Name: "Host", Value: "admin.example.com"},
+			{Name: "User-Agent", Value: "test-agent"},
+			{Name: "Accept", Value: "text/html"},
+			{Name: "Connection", Value: "close"},
+			{Name: "Cookie", Value: "session=abc"},
+			{Name: "Accept-Encoding", Value: "gzip"},
+		}},
+		{VariantXForwardedHost, []HeaderField{
+			{Name: "Host", Value: server.Listener.Addr().String()},
+			{Name: "User-Agent", Value: "test-agent"},
+			{Name: "Accept", Value: "text/html"},
+			{Name: "Connection", Value: "close"},
+			{Name: "Cookie", Value: "session=abc"},
+			{Name: "X-Forwarded-Host", Value: "admin.example.com"},
+			{Name: "Accept-Encoding", Value: "gzip"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.variant, func(t *testing.T) {
+			response, err := NewHTTPRequester(scanner, test.variant, client, nil).RequestVHost(context.Background(), server.URL, "admin.example.com")
+			if err != nil {
+				t.Fatalf("RequestVHost: %v", err)
+			}
+
+			if !slices.Equal(response.Request.Headers, test.want) {
+				t.Errorf("sent headers:\n%v\nwant:\n%v", response.Request.Headers, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	RuleActionHit    = "hit"
+	RuleActionIgnore = "ignore"
+)
+
+var RuleVariables = []string{
+	"target", "vhost", "path",
+	"response.status", "response.title", "response.body", "response.length", "response.words",
+	"response.lines", "response.time", "response.protocol", "response.variant", "response.fingerprint",
+	"response.headers",
+	"baseline.status", "baseline.statuses", "baseline.title", "baseline.titles", "baseline.length",
+	"baseline.lengths", "baseline.body",
+}
+
+var RuleFunctions = []string{"similarity", "header", "len", "lower"}
+
+type Rule struct {
+	Action     string
+	Expression *Expression
+	Source     string
+}
+
+type RuleSet struct {
+	Global  []Rule
+	Targets []TargetRules
+}
+
+type TargetRules struct {
+	Pattern string
+	Rules   []Rule
+}
+
+func LoadRuleSet(filePath string) (*RuleSet, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rules file: %w", err)
+	}
+	defer file.Close()
+
+	return ParseRuleSet(file, filePath)
+}
+
+func ParseRuleSet(reader io.Reader, name string) (*RuleSet, error) {
+	ruleSet := &RuleSet{}
+	section := -1
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(text, " ")
+		rest = strings.TrimSpace(rest)
+
+		switch keyword {
+		case "target":
+			if rest == "" {
+				return nil, fmt.Errorf("%s:%d: target needs a URL or host pattern", name, line)
+			}
+			if _, err := path.Match(rest, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid target pattern %q: %w", name, line, rest, err)
+			}
+			ruleSet.Targets = append(ruleSet.Targets, TargetRules{Pattern: rest})
+			section = len(ruleSet.Targets) - 1
+
+		case RuleActionHit, RuleActionIgnore:
+			expression, err := ParseExpression(rest)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+			if err := expression.Check(RuleVariables, RuleFunctions); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+
+			rule := Rule{Action: keyword, Expression: expression, Source: fmt.Sprintf("%s:%d", name, line)}
+			if section >= 0 {
+				ruleSet.Targets[section].Rules = append(ruleSet.Targets[section].Rules, rule)
+			} else {
+				ruleSet.Global = append(ruleSet.Global, rule)
+			}
+
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown keyword %q, expected %s, %s or target", name, line, keyword, RuleActionHit, RuleActionIgnore)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	return ruleSet, nil
+}
+
+func (r *RuleSet) ForTarget(target string) []Rule {
+	if r == nil {
+		return nil
+	}
+
+	var rules []Rule
+	host := GetHostFromURL(target)
+	for _, section := range r.Targets {
+		if matchesTargetPattern(section.Pattern, target, host) {
+			rules = append(rules, section.Rules...)
+		}
+	}
+
+	return append(rules, r.Global...)
+}
+
+func matchesTargetPattern(pattern string, target string, host string) bool {
+	if matched, _ := path.Match(pattern, target); matched {
+		return true
+	}
+	matched, _ := path.Match(pattern, host)
+	return matched
+}
+
+func (s *Session) isHit(baseline BaselineResponse, vhost string, probePath string, response FullResponse) bool {
+	if len(s.Rules) > 0 {
+		env := s.ruleEnv(baseline, vhost, probePath, &response)
+		for _, rule := range s.Rules {
+			matched, err := rule.Expression.Evaluate(env)
+			if err != nil {
+				s.Scanner.Log(fmt.Sprintf("Rule %s failed for %s on %s: %v", rule.Source, vhost, s.Target, err))
+				continue
+			}
+			if matched {
+				return rule.Action == RuleActionHit
+			}
+		}
+	}
+
+	return s.isDifferent(baseline, response)
+}
+
+func (s *Session) ruleEnv(baseline BaselineResponse, vhost string, probePath string, response *FullResponse) *expressionEnv {
+	headers := make(headerMap)
+	for name, values := range response.Headers {
+		headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+	}
+
+	statuses := make([]any, 0, len(baseline.StatusCodes))
+	for _, status := range baseline.StatusCodes {
+		statuses = append(statuses, float64(status))
+	}
+	titles := make([]any, 0, len(baseline.Titles))
+	for _, title := range baseline.Titles {
+		titles = append(titles, title)
+	}
+	lengths := make([]any, 0, len(baseline.Bodies))
+	for _, body := range baseline.Bodies {
+		lengths = append(lengths, float64(len(body)))
+	}
+
+	variables := map[string]any{
+		"target":               s.Target,
+		"vhost":                vhost,
+		"path":                 probePath,
+		"response.status":      float64(response.StatusCode),
+		"response.title":       response.Title,
+		"response.body":        response.Body,
+		"response.length":      float64(len(response.Body)),
+		"response.words":       float64(CountWords(response.Body)),
+		"response.lines":       float64(CountLines(response.Body)),
+		"response.time":        float64(response.Duration.Milliseconds()),
+		"response.protocol":    response.Protocol,
+		"response.variant":     response.Variant,
+		"response.fingerprint": response.fingerprint(),
+		"response.headers":     headers,
+		"baseline.status":      0.0,
+		"baseline.statuses":    statuses,
+		"baseline.title":       "",
+		"baseline.titles":      titles,
+		"baseline.length":      0.0,
+		"baseline.lengths":     lengths,
+		"baseline.body":        "",
+	}
+	if len(statuses) > 0 {
+		variables["baseline.status"] = statuses[0]
+	}
+	if len(titles) > 0 {
+		variables["baseline.title"] = titles[0]
+	}
+	if len(baseline.Bodies) > 0 {
+		variables["baseline.length"] = lengths[0]
+		variables["baseline.body"] = baseline.Bodies[0]
+	}
+
+	functions := map[string]func(args []any) (any, error){
+		"similarity": func(args []any) (any, error) {
+			switch len(args) {
+			case 0:
+				highest := 0.0
+				for _, body := range baseline.Bodies {
+					highest = max(highest, CalculateSimilarity(response.Body, body))
+				}
+				return highest, nil
+			case 2:
+				left, leftOK := args[0].(string)
+				right, rightOK := args[1].(string)
+				if leftOK && rightOK {
+					return CalculateSimilarity(left, right), nil
+				}
+			}
+			return nil, fmt.Errorf("similarity() takes no arguments or two strings")
+		},
+		"header": func(args []any) (any, error) {
+			if len(args) == 1 {
+				if name, ok := args[0].(string); ok {
+					return headers[http.CanonicalHeaderKey(name)], nil
+				}
+			}
+			return nil, fmt.Errorf("header() takes one header name")
+		},
+		"len": func(args []any) (any, error) {
+			if len(args) == 1 {
+				switch value := args[0].(type) {
+				case string:
+					return float64(len(value)), nil
+				case []any:
+					return float64(len(value)), nil
+				case headerMap:
+					return float64(len(value)), nil
+				}
+			}
+			return nil, fmt.Errorf("len() takes one string, list or headers")
+		},
+		"lower": func(args []any) (any, error) {
+			if len(args) == 1 {
+				if value, ok := args[0].(string); ok {
+					return strings.ToLower(value), nil
+				}
+			}
+			return nil, fmt.Errorf("lower() takes one string")
+		},
+	}
+
+	return &expressionEnv{variables: variables, functions: functions}
+}
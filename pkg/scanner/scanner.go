@@ -1,38 +1,85 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
-
-	"github.com/fatih/color"
-	"github.com/schollz/progressbar/v3"
+	"sync/atomic"
+	"time"
 )
 
 type Scanner struct {
-	Targets  []string
-	Wordlist []string
+	Targets  *StreamList
+	Wordlist *StreamList
 	Options  ScannerOptions
 
-	httpClient         *http.Client
-	requester          *Requester
-	progressBar        *progressbar.ProgressBar
+	requester          Requester
+	variants           []string
 	totalVHosts        int
-	outputWriter       *OutputWriter
+	progress           Progress
+	hookMutex          sync.Mutex
+	hitSubscribers     []*hitSubscriber
+	writers            []ResultWriter
+	harWriter          *HARWriter
+	proxyURL           *url.URL
+	syncStop           chan struct{}
+	syncWG             sync.WaitGroup
+	outputMutex        sync.RWMutex
+	ipMatrix           *IPMatrix
+	checkpointer       *Checkpointer
+	hitCount           atomic.Int64
+	completedTargets   atomic.Int64
 	accessibilityCache map[string]bool
 	cacheMutex         sync.RWMutex
 }
 
 type ScannerOptions struct {
-	Threads          int
-	ConcurrentVHosts int
-	Verbose          bool
-	Internal         bool
-	OutputFile       string
-	Minimal          bool
+	Threads             int
+	ConcurrentVHosts    int
+	Verbose             bool
+	Internal            bool
+	Outputs             []Output
+	Writers             []ResultWriter
+	Minimal             bool
+	HTTP3               bool
+	RawVariants         []string
+	OverrideModes       []string
+	Paths               []string
+	TargetPaths         *TargetPaths
+	Seeds               map[string][]string
+	IPMode              bool
+	OriginMode          bool
+	StateFile           string
+	Resume              bool
+	DrainTimeout        time.Duration
+	SimilarityThreshold float64
+	Matcher             *ResponseMatcher
+	Filter              *ResponseMatcher
+	Rules               *RuleSet
+	Headers             []HeaderField
+	UserAgent           string
+	Proxy               string
+	RateLimit           float64
+	RecordFile          string
+	HARFile             string
+	HARBodyLimit        int
+	SyncInterval        time.Duration
+	RotateSize          int64
+	AppendOutput        bool
+	Requester           Requester
+	Hooks               Hooks
+}
+
+func NewScanner(targets []string, wordlist []string, options ScannerOptions) (*Scanner, error) {
+	return NewStreamingScanner(NewStaticList(targets), NewStaticList(wordlist), options)
 }
 
-func NewScanner(targets []string, wordlist []string, options ScannerOptions) *Scanner {
+func NewStreamingScanner(targets *StreamList, wordlist *StreamList, options ScannerOptions) (*Scanner, error) {
 	scanner := &Scanner{
 		Targets:            targets,
 		Wordlist:           wordlist,
@@ -44,158 +91,416 @@ func NewScanner(targets []string, wordlist []string, options ScannerOptions) *Sc
 		scanner.Options.Threads = 1
 	}
 
-	scanner.requester = NewRequester(scanner)
-	scanner.httpClient = scanner.requester.newHTTPClient()
-	scanner.totalVHosts = len(targets) * len(wordlist)
+	if scanner.Options.ConcurrentVHosts <= 0 {
+		scanner.Options.ConcurrentVHosts = 10
+	}
+
+	if scanner.Options.Minimal && (scanner.Options.Matcher.countsBody() || scanner.Options.Filter.countsBody()) {
+		return nil, fmt.Errorf("word and line count matchers cannot be combined with minimal mode, which only reads the first %d bytes of each body", minimalBodyLimit)
+	}
+
+	if scanner.Options.DrainTimeout <= 0 {
+		scanner.Options.DrainTimeout = 5 * time.Second
+	}
+
+	if scanner.Options.SyncInterval <= 0 {
+		scanner.Options.SyncInterval = 5 * time.Second
+	}
+
+	if scanner.Options.SimilarityThreshold <= 0 {
+		scanner.Options.SimilarityThreshold = DefaultSimilarityThreshold
+	}
+
+	var headers []HeaderField
+	for _, header := range scanner.Options.Headers {
+		if strings.EqualFold(header.Name, "User-Agent") {
+			scanner.Options.UserAgent = header.Value
+			continue
+		}
+		headers = append(headers, HeaderField{Name: http.CanonicalHeaderKey(header.Name), Value: header.Value})
+	}
+	scanner.Options.Headers = headers
+
+	if scanner.Options.UserAgent == "" {
+		scanner.Options.UserAgent = DefaultUserAgent
+	}
+
+	if scanner.Options.Proxy != "" {
+		if len(scanner.Options.RawVariants) > 0 || scanner.Options.HTTP3 {
+			return nil, fmt.Errorf("a proxy cannot be used together with raw variants or HTTP/3")
+		}
+
+		var err error
+		scanner.proxyURL, err = ParseProxy(scanner.Options.Proxy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scanner.totalVHosts = scanner.countVHosts()
+
+	scanner.variants = []string{VariantHost}
+	if len(scanner.Options.RawVariants) > 0 {
+		scanner.variants = slices.Clone(scanner.Options.RawVariants)
+	}
+	for _, mode := range scanner.Options.OverrideModes {
+		if !slices.Contains(scanner.variants, mode) {
+			scanner.variants = append(scanner.variants, mode)
+		}
+	}
+
+	scanner.requester = scanner.Options.Requester
+	if scanner.requester == nil {
+		scanner.requester = NewNetworkRequester(scanner)
+	}
+
+	if scanner.Options.RecordFile != "" {
+		recorder, err := NewRecordingRequester(scanner.requester, scanner.Options.RecordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize recording: %w", err)
+		}
+		scanner.requester = recorder
+	}
+
+	if scanner.Options.IPMode {
+		scanner.ipMatrix = NewIPMatrix()
+	}
+
+	if scanner.Options.StateFile != "" {
+		var inputs *CheckpointInputs
+		if !scanner.IsStreaming() {
+			inputs = NewCheckpointInputs(scanner.Targets.All(), scanner.Wordlist.All(), scanner.Options.Internal)
+		}
 
-	if options.OutputFile != "" {
 		var err error
-		scanner.outputWriter, err = NewOutputWriter(options.OutputFile)
+		scanner.checkpointer, err = NewCheckpointer(scanner.Options.StateFile, scanner.Options.Resume, inputs, func(err error) {
+			scanner.emitError("", err)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize state file: %w", err)
+		}
+	}
+
+	scanner.writers = slices.Clone(options.Writers)
+	for _, output := range options.Outputs {
+		writer, err := NewResultWriter(output, scanner.writerOptions())
 		if err != nil {
-			fmt.Printf("Warning: Failed to initialize output writer: %v\n", err)
-		} else {
-			fmt.Printf("Results will be saved to %s\n", options.OutputFile)
+			scanner.Close()
+			return nil, fmt.Errorf("failed to initialize output writer: %w", err)
+		}
+		scanner.writers = append(scanner.writers, writer)
+	}
+
+	if options.HARFile != "" {
+		var err error
+		scanner.harWriter, err = NewHARWriter(options.HARFile, options.HARBodyLimit)
+		if err != nil {
+			scanner.Close()
+			return nil, err
+		}
+	}
+
+	scanner.syncStop = make(chan struct{})
+	scanner.syncWG.Add(1)
+	go scanner.syncLoop()
+
+	return scanner, nil
+}
+
+func (s *Scanner) syncLoop() {
+	defer s.syncWG.Done()
+
+	ticker := time.NewTicker(s.Options.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.syncOutputs()
+		case <-s.syncStop:
+			return
 		}
 	}
+}
+
+func (s *Scanner) syncOutputs() {
+	s.outputMutex.RLock()
+	defer s.outputMutex.RUnlock()
+
+	for _, writer := range s.writers {
+		if syncer, ok := writer.(SyncWriter); ok {
+			if err := syncer.Sync(); err != nil {
+				s.emitError("", err)
+			}
+		}
+	}
+
+	if s.harWriter != nil {
+		if err := s.harWriter.Sync(); err != nil {
+			s.emitError("", err)
+		}
+	}
+}
+
+func (s *Scanner) SeedsFor(target string) []string {
+	var seeds []string
+	for _, seed := range s.Options.Seeds[GetHostFromURL(target)] {
+		if !s.Wordlist.Contains(seed) {
+			seeds = append(seeds, seed)
+		}
+	}
+	return seeds
+}
+
+func (s *Scanner) countVHosts() int {
+	if !s.Targets.Closed() || !s.Wordlist.Closed() {
+		return -1
+	}
+
+	total := 0
+	for _, target := range s.Targets.All() {
+		total += s.Wordlist.Len() + len(s.SeedsFor(target))
+	}
+	return total
+}
 
-	return scanner
+func (s *Scanner) IsStreaming() bool {
+	return !s.Targets.Closed() || !s.Wordlist.Closed()
+}
+
+func (s *Scanner) Variants() []string {
+	return s.variants
+}
+
+func (s *Scanner) ProbePaths(target string) []string {
+	return GetProbePaths(target, slices.Concat(s.Options.Paths, s.Options.TargetPaths.For(target)))
+}
+
+func (s *Scanner) usesRawRequester() bool {
+	return len(s.Options.RawVariants) > 0
+}
+
+func (s *Scanner) TotalVHosts() int {
+	return s.totalVHosts
+}
+
+func (s *Scanner) Matrix() *IPMatrix {
+	return s.ipMatrix
 }
 
 func (s *Scanner) SetOutputFile(filePath string) error {
-	if s.outputWriter != nil {
-		s.outputWriter.Close()
+	output, err := ParseOutput(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to set output file: %w", err)
 	}
 
-	var err error
-	s.outputWriter, err = NewOutputWriter(filePath)
+	writer, err := NewResultWriter(output, s.writerOptions())
 	if err != nil {
 		return fmt.Errorf("failed to set output file: %w", err)
 	}
 
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+
+	for _, existing := range s.writers {
+		existing.Close()
+	}
+	s.writers = []ResultWriter{writer}
+	s.Options.Outputs = []Output{output}
+
 	return nil
 }
 
-func (s *Scanner) Scan() {
+func (s *Scanner) setRequestHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", s.Options.UserAgent)
+	for _, header := range s.Options.Headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+}
+
+func (s *Scanner) writerOptions() WriterOptions {
+	return WriterOptions{RotateSize: s.Options.RotateSize, Append: s.Options.AppendOutput}
+}
+
+func (s *Scanner) writeHit(target string, result SessionResult) {
+	s.outputMutex.RLock()
+	defer s.outputMutex.RUnlock()
+
+	for _, writer := range s.writers {
+		if err := writer.WriteHit(target, result); err != nil {
+			s.emitError(target, err)
+		}
+	}
+}
+
+func (s *Scanner) writeResults(target string, results []SessionResult) {
+	s.outputMutex.RLock()
+	defer s.outputMutex.RUnlock()
+
+	for _, writer := range s.writers {
+		if err := writer.WriteResults(target, results); err != nil {
+			s.emitError(target, err)
+		}
+	}
+}
+
+func (s *Scanner) writeOriginResult(result OriginResult) {
+	s.outputMutex.RLock()
+	defer s.outputMutex.RUnlock()
+
+	for _, writer := range s.writers {
+		if err := writer.WriteOriginResult(result); err != nil {
+			s.emitError(result.Hostname, err)
+		}
+	}
+}
+
+func (s *Scanner) Scan(ctx context.Context) ScanSummary {
+	if s.Options.OriginMode {
+		s.ScanOrigins(ctx)
+		return ScanSummary{Interrupted: ctx.Err() != nil}
+	}
+
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, s.Options.Threads)
 
-	s.progressBar = progressbar.NewOptions(s.totalVHosts,
-		progressbar.OptionSetDescription("Scanning virtual hosts"),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "=",
-			SaucerHead:    ">",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionClearOnFinish(),
-		progressbar.OptionSetItsString("vhosts"),
-		progressbar.OptionShowCount(),
-		progressbar.OptionSetPredictTime(true),
-		progressbar.OptionEnableColorCodes(true),
-	)
-
-	if s.Options.Internal {
-		fmt.Println("Using internal hosts filter - only hosts that are NOT directly accessible will be checked")
-	}
-
-	fmt.Printf("Starting scan with %d targets and %d hostnames in wordlist (%d total vhosts)\n",
-		len(s.Targets), len(s.Wordlist), s.totalVHosts)
-
-	for _, target := range s.Targets {
+	s.startPhase(PhaseScan, s.totalVHosts)
+
+	dispatched := 0
+	for i := 0; ; i++ {
+		target, ok := s.Targets.Next(ctx, i)
+		if !ok {
+			break
+		}
+
+		if !acquire(ctx, semaphore) {
+			break
+		}
+
 		wg.Add(1)
-		semaphore <- struct{}{}
+		dispatched++
 		go func(target string) {
 			defer wg.Done()
 			defer func() { <-semaphore }()
-			s.Log(fmt.Sprintf("Scanning %s", target))
-			s.scanTarget(target)
+			s.emitTargetStart(target)
+			s.emitTargetDone(target, s.scanTarget(ctx, target))
 		}(target)
 	}
 
 	wg.Wait()
 
-	if s.progressBar != nil {
-		s.progressBar.Clear()
-		s.progressBar.Close()
+	return ScanSummary{
+		Hits:             int(s.hitCount.Load()),
+		StartedTargets:   dispatched,
+		CompletedTargets: int(s.completedTargets.Load()),
+		Interrupted:      ctx.Err() != nil,
 	}
+}
 
-	fmt.Printf("\nScan completed!\n")
-
-	if s.outputWriter != nil && s.Options.OutputFile != "" {
-		fmt.Printf("Results saved to %s\n", s.Options.OutputFile)
+func (s *Scanner) checkpointTarget(target string) (TargetCheckpoint, bool) {
+	if s.checkpointer == nil {
+		return TargetCheckpoint{}, false
 	}
+	return s.checkpointer.Target(target)
 }
 
-func (s *Scanner) scanTarget(target string) {
+func (s *Scanner) scanTarget(ctx context.Context, target string) TargetSummary {
+	if checkpoint, ok := s.checkpointTarget(target); ok && checkpoint.Completed {
+		s.Log(fmt.Sprintf("Target %s was completed in a previous run, skipping", target))
+
+		results := checkpoint.RestoredResults()
+		for _, result := range results {
+			s.writeHit(target, result)
+			s.emitHit(target, result)
+		}
+		s.writeResults(target, results)
+
+		s.hitCount.Add(int64(len(results)))
+		s.completedTargets.Add(1)
+		s.UpdateProgress(s.Wordlist.Len() + len(s.SeedsFor(target)))
+		return TargetSummary{Alive: true, Restored: true, Hits: len(results)}
+	}
+
+	requestCtx, cancel := drainContext(ctx, s.Options.DrainTimeout)
+	defer cancel()
+
+	headers, err := s.requester.AliveCheck(requestCtx, target)
+	if err != nil {
+		if ctx.Err() != nil {
+			return TargetSummary{Interrupted: true}
+		}
+
+		s.Log(fmt.Sprintf("Target %s is not alive, skipping: %v", target, err))
+		s.UpdateProgress(s.Wordlist.Len() + len(s.SeedsFor(target)))
+		s.completedTargets.Add(1)
+		return TargetSummary{}
+	}
 
 	session := NewSession(s, target)
+	if s.Options.HTTP3 {
+		session.detectHTTP3(requestCtx, headers.Get("Alt-Svc"))
+	}
+
+	written := make(chan struct{})
 	go func() {
-		results := session.Scan()
-		if s.outputWriter != nil {
-			s.outputWriter.WriteResults(target, results)
+		defer close(written)
+
+		results := session.Scan(ctx)
+		s.writeResults(target, results)
+
+		if ctx.Err() == nil {
+			s.completedTargets.Add(1)
 		}
 	}()
 
+	hits := 0
 	for result := range session.Results {
-		s.printResult(result, target)
+		hits++
+		s.hitCount.Add(1)
+		s.writeHit(target, result)
+		s.emitHit(target, result)
 	}
-}
 
-func (s *Scanner) printResult(result SessionResult, target string) {
-	if result.IsVHost {
-		accessibleStr := "✓"
-		if !result.IsAccessible {
-			accessibleStr = "✗"
-		}
+	<-written
 
-		var resultStr string
-		statusColor := color.New(color.FgGreen)
-		if result.Response.StatusCode >= 400 {
-			statusColor = color.New(color.FgRed)
-		} else if result.Response.StatusCode >= 300 {
-			statusColor = color.New(color.FgYellow)
-		}
-
-		accessibleColor := color.New(color.FgGreen)
-		if !result.IsAccessible {
-			accessibleColor = color.New(color.FgRed)
-		}
+	return TargetSummary{Alive: true, Interrupted: ctx.Err() != nil, Hits: hits}
+}
 
-		resultStr = fmt.Sprintf("%s - %s [%s] [%s] [Accessible: %s]",
-			color.YellowString(target),
-			color.CyanString(result.VHost),
-			statusColor.Sprintf("%d", result.Response.StatusCode),
-			color.WhiteString(result.Response.Title),
-			accessibleColor.Sprint(accessibleStr),
-		)
+func (s *Scanner) Close() error {
+	var closeErr error
 
-		s.progressBar.Clear()
-		fmt.Println(resultStr)
-		s.progressBar.RenderBlank()
+	if s.syncStop != nil {
+		close(s.syncStop)
+		s.syncWG.Wait()
+		s.syncStop = nil
 	}
-}
 
-func (s *Scanner) UpdateProgress(count int) {
-	if s.progressBar != nil {
-		s.progressBar.Add(count)
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+
+	for _, writer := range s.writers {
+		if err := writer.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
 	}
-}
 
-func (s *Scanner) Log(message string) {
-	if s.Options.Verbose {
-		if s.progressBar != nil {
-			s.progressBar.Clear()
+	if s.harWriter != nil {
+		if err := s.harWriter.Close(); err != nil && closeErr == nil {
+			closeErr = err
 		}
-		fmt.Println(message)
-		if s.progressBar != nil {
-			s.progressBar.RenderBlank()
+	}
+
+	if s.checkpointer != nil {
+		if err := s.checkpointer.Close(); err != nil && closeErr == nil {
+			closeErr = err
 		}
 	}
-}
 
-func (s *Scanner) Close() {
-	if s.outputWriter != nil {
-		s.outputWriter.Close()
+	if closer, ok := s.requester.(io.Closer); ok {
+		if err := closer.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
 	}
+
+	return closeErr
 }
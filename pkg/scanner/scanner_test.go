@@ -0,0 +1,259 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const defaultPage = "<title>Welcome</title> default page of the server"
+
+type cannedRequester struct {
+	mutex  sync.Mutex
+	altSvc string
+	pages  map[string]string
+	probes []Probe
+}
+
+func (r *cannedRequester) Probe(ctx context.Context, probe Probe) (*FullResponse, error) {
+	r.mutex.Lock()
+	r.probes = append(r.probes, probe)
+	r.mutex.Unlock()
+
+	body, ok := r.pages[probe.VHost]
+	if !ok {
+		body = defaultPage
+	}
+
+	protocol := "HTTP/1.1"
+	if probe.HTTP3 {
+		protocol = "HTTP/3.0"
+	}
+
+	return &FullResponse{
+		Body:          body,
+		Title:         ExtractTitle(body),
+		StatusCode:    http.StatusOK,
+		ContentLength: len(body),
+		Protocol:      protocol,
+		Variant:       probe.Variant,
+		Headers:       http.Header{},
+	}, nil
+}
+
+func (r *cannedRequester) AliveCheck(ctx context.Context, target string) (http.Header, error) {
+	headers := http.Header{}
+	if r.altSvc != "" {
+		headers.Set("Alt-Svc", r.altSvc)
+	}
+	return headers, nil
+}
+
+func (r *cannedRequester) IsAccessible(ctx context.Context, vhost string) bool {
+	return false
+}
+
+func (r *cannedRequester) sent() []Probe {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return slices.Clone(r.probes)
+}
+
+func newCannedRequester() *cannedRequester {
+	return &cannedRequester{pages: map[string]string{
+		"admin.example.com": "<title>Admin</title> internal administration panel with a login form",
+	}}
+}
+
+func TestScanReportsHits(t *testing.T) {
+	requester := newCannedRequester()
+
+	scanner, err := NewScanner(
+		[]string{"https://example.com"},
+		[]string{"www.example.com", "admin.example.com", "mail.example.com"},
+		ScannerOptions{Requester: requester},
+	)
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	defer scanner.Close()
+
+	var hits []Hit
+	for hit := range scanner.Hits(context.Background()) {
+		hits = append(hits, hit)
+	}
+
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1: %+v", len(hits), hits)
+	}
+	if hits[0].Target != "https://example.com" || hits[0].Result.VHost != "admin.example.com" {
+		t.Errorf("got hit %s on %s, want admin.example.com on https://example.com", hits[0].Result.VHost, hits[0].Target)
+	}
+	if hits[0].Result.Response.Title != "Admin" {
+		t.Errorf("hit title %q, want Admin", hits[0].Result.Response.Title)
+	}
+}
+
+func TestIPMatrixMarksServedHostnames(t *testing.T) {
+	scanner, err := NewScanner(
+		[]string{"https://10.0.0.1", "https://10.0.0.2"},
+		[]string{"www.example.com", "admin.example.com"},
+		ScannerOptions{Requester: newCannedRequester(), IPMode: true},
+	)
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	defer scanner.Close()
+
+	scanner.Scan(context.Background())
+
+	entries := scanner.Matrix().Entries()
+	want := map[string]bool{"admin.example.com": true, "www.example.com": false}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d matrix entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for _, entry := range entries {
+		if len(entry.Groups) != 1 {
+			t.Fatalf("%s has %d groups, want 1", entry.Host, len(entry.Groups))
+		}
+		group := entry.Groups[0]
+		if group.Served != want[entry.Host] || !slices.Equal(group.Targets, []string{"https://10.0.0.1", "https://10.0.0.2"}) {
+			t.Errorf("%s: served=%v targets=%v, want served=%v on both targets", entry.Host, group.Served, group.Targets, want[entry.Host])
+		}
+	}
+}
+
+func TestRemoveNonInternalHostsWithDefaultConcurrency(t *testing.T) {
+	scanner, err := NewScanner(
+		[]string{"https://example.com"},
+		[]string{"www.example.com", "admin.example.com"},
+		ScannerOptions{Requester: newCannedRequester(), ConcurrentVHosts: 0},
+	)
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	defer scanner.Close()
+
+	original, internal, err := scanner.RemoveNonInternalHosts(context.Background())
+	if err != nil || original != 2 || internal != 2 {
+		t.Errorf("RemoveNonInternalHosts = %d, %d, %v; want 2, 2, nil", original, internal, err)
+	}
+}
+
+func TestLearnBaselines(t *testing.T) {
+	requester := newCannedRequester()
+
+	scanner, err := NewScanner([]string{"https://example.com"}, nil, ScannerOptions{
+		Requester:     requester,
+		OverrideModes: []string{VariantXForwardedHost},
+		Paths:         []string{"/login"},
+	})
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	defer scanner.Close()
+
+	learned, err := scanner.LearnBaselines(context.Background(), "https://example.com")
+	if err != nil {
+		t.Fatalf("LearnBaselines: %v", err)
+	}
+
+	if len(learned.Probes) != 4 {
+		t.Fatalf("got %d probes, want 4 (2 variants x 2 paths)", len(learned.Probes))
+	}
+
+	for _, probe := range learned.Probes {
+		if len(probe.Samples) != 3 {
+			t.Errorf("%s %s: got %d samples, want 3", probe.Variant, probe.Path, len(probe.Samples))
+		}
+		if len(probe.Clusters) != 1 || len(probe.Clusters[0].VHosts) != 3 {
+			t.Errorf("%s %s: got clusters %+v, want a single cluster of 3", probe.Variant, probe.Path, probe.Clusters)
+		}
+		if len(probe.Similarity) != 3 {
+			t.Errorf("%s %s: got %d similarity scores, want 3", probe.Variant, probe.Path, len(probe.Similarity))
+		}
+		if !slices.Equal(probe.StatusCodes, []int{http.StatusOK}) || !slices.Equal(probe.Titles, []string{"Welcome"}) {
+			t.Errorf("%s %s: learned %v %v, want [200] [Welcome]", probe.Variant, probe.Path, probe.StatusCodes, probe.Titles)
+		}
+	}
+
+	for _, probe := range requester.sent() {
+		if !probe.Baseline {
+			t.Errorf("probe for %s was not marked as a baseline probe", probe.VHost)
+		}
+	}
+}
+
+func TestHTTP3Routing(t *testing.T) {
+	tests := []struct {
+		name        string
+		rawVariants []string
+		http3       bool
+	}{
+		{"http requester uses h3", nil, true},
+		{"raw variants stay on tcp", []string{VariantHost, VariantAbsoluteURI}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requester := newCannedRequester()
+			requester.altSvc = `h3=":8443"; ma=86400`
+
+			scanner, err := NewScanner([]string{"https://example.com"}, []string{"admin.example.com"}, ScannerOptions{
+				Requester:   requester,
+				HTTP3:       true,
+				RawVariants: test.rawVariants,
+			})
+			if err != nil {
+				t.Fatalf("NewScanner: %v", err)
+			}
+			defer scanner.Close()
+
+			summary := scanner.Scan(context.Background())
+			if summary.Hits != len(scanner.Variants()) {
+				t.Errorf("got %d hits, want one per variant (%d)", summary.Hits, len(scanner.Variants()))
+			}
+
+			for _, probe := range requester.sent() {
+				if probe.Variant == "" {
+					if !probe.HTTP3 || probe.URL != "https://example.com:8443" {
+						t.Errorf("HTTP/3 detection probe sent to %s (h3: %v)", probe.URL, probe.HTTP3)
+					}
+					continue
+				}
+
+				wantURL := "https://example.com"
+				if test.http3 {
+					wantURL = "https://example.com:8443"
+				}
+				if probe.HTTP3 != test.http3 || !strings.HasPrefix(probe.URL, wantURL+"/") {
+					t.Errorf("%s probe for %s sent to %s (h3: %v), want %s (h3: %v)",
+						probe.Variant, probe.VHost, probe.URL, probe.HTTP3, wantURL, test.http3)
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkRequesterRejectsHTTP3ForRawVariants(t *testing.T) {
+	scanner, err := NewScanner(nil, nil, ScannerOptions{HTTP3: true, RawVariants: []string{VariantHost}})
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	defer scanner.Close()
+
+	_, err = scanner.requester.Probe(context.Background(), Probe{
+		URL:     "https://127.0.0.1:1",
+		VHost:   "example.com",
+		Variant: VariantHost,
+		HTTP3:   true,
+	})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("variant %s cannot be sent over HTTP/3", VariantHost)) {
+		t.Errorf("got error %v, want a refusal to send a raw variant over HTTP/3", err)
+	}
+}
@@ -1,35 +1,51 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
-	"net"
-	"net/http"
+	"iter"
 	"slices"
+	"strings"
 	"sync"
 )
 
+const DefaultSimilarityThreshold = 40
+
 type SessionResult struct {
 	VHost        string
+	Path         string
 	Response     *SlimResponse
 	IsVHost      bool
 	IsAccessible bool
 }
 
 type Session struct {
-	Scanner          *Scanner
-	Target           string
-	Results          chan SessionResult
-	WaitGroup        *sync.WaitGroup
-	BaselineResponse BaselineResponse
+	Scanner     *Scanner
+	Target      string
+	Paths       []string
+	Seeds       []string
+	Rules       []Rule
+	Results     chan SessionResult
+	WaitGroup   *sync.WaitGroup
+	Baselines   map[ProbeKey]BaselineResponse
+	HTTP3Target string
+}
+
+type ProbeKey struct {
+	Variant string
+	Path    string
 }
 
 func NewSession(scanner *Scanner, target string) *Session {
 	return &Session{
-		Scanner:          scanner,
-		Target:           target,
-		Results:          make(chan SessionResult, 100),
-		BaselineResponse: BaselineResponse{},
-		WaitGroup:        &sync.WaitGroup{},
+		Scanner:   scanner,
+		Target:    target,
+		Paths:     scanner.ProbePaths(target),
+		Seeds:     scanner.SeedsFor(target),
+		Rules:     scanner.Options.Rules.ForTarget(target),
+		Results:   make(chan SessionResult, 100),
+		Baselines: make(map[ProbeKey]BaselineResponse),
+		WaitGroup: &sync.WaitGroup{},
 	}
 }
 
@@ -39,49 +55,80 @@ type BaselineResponse struct {
 	Bodies      []string
 }
 
-func (s *Session) aliveCheck() bool {
-	_, err := net.LookupHost(GetHostFromURL(s.Target))
-	if err != nil {
-		return false
+func (s *Session) detectHTTP3(ctx context.Context, altSvc string) {
+	if !strings.HasPrefix(s.Target, "https://") {
+		return
 	}
 
-	req, err := http.NewRequest("GET", s.Target, nil)
-	if err != nil {
-		return false
+	h3Target, ok := GetHTTP3TargetFromAltSvc(s.Target, altSvc)
+	if !ok {
+		return
 	}
 
-	req.Header.Set("User-Agent", "go-vhosts/1.0")
-	req.Header.Set("Connection", "close")
-
-	resp, err := s.Scanner.httpClient.Do(req)
-	if err != nil {
-		return false
+	probe := Probe{URL: h3Target, VHost: GetHostFromURL(s.Target), HTTP3: true}
+	if _, err := s.Scanner.requester.Probe(ctx, probe); err != nil {
+		s.Scanner.Log(fmt.Sprintf("Target %s advertises HTTP/3 but probe failed: %v", s.Target, err))
+		return
 	}
 
-	if resp != nil && resp.Body != nil {
-		defer resp.Body.Close()
+	s.Scanner.Log(fmt.Sprintf("Target %s supports HTTP/3, probing vhosts over h3", s.Target))
+	s.HTTP3Target = h3Target
+}
+
+func (s *Session) buildProbe(probe ProbeKey, vhost string) Probe {
+	if s.HTTP3Target != "" && !s.Scanner.usesRawRequester() {
+		return Probe{
+			URL:     SetURLPath(s.HTTP3Target, probe.Path),
+			VHost:   vhost,
+			Variant: probe.Variant,
+			HTTP3:   true,
+		}
 	}
 
-	return resp != nil && resp.StatusCode > 0
+	return Probe{
+		URL:     SetURLPath(s.Target, probe.Path),
+		VHost:   vhost,
+		Variant: probe.Variant,
+	}
 }
 
-func (s *Session) Scan() []SessionResult {
-	if !s.aliveCheck() {
-		s.Scanner.Log(fmt.Sprintf("Target %s is not alive, skipping", s.Target))
+func (s *Session) requestVHost(ctx context.Context, probe ProbeKey, vhost string) (*FullResponse, error) {
+	return s.Scanner.requester.Probe(ctx, s.buildProbe(probe, vhost))
+}
 
-		skippedCount := len(s.Scanner.Wordlist)
-		s.Scanner.UpdateProgress(skippedCount)
+func (s *Session) Scan(ctx context.Context) []SessionResult {
+	requestCtx, cancel := drainContext(ctx, s.Scanner.Options.DrainTimeout)
+	defer cancel()
+
+	checkpoint, resumed := s.Scanner.checkpointTarget(s.Target)
+	if resumed && len(checkpoint.Baselines) > 0 {
+		s.Baselines = checkpoint.RestoredBaselines()
+		s.HTTP3Target = checkpoint.HTTP3Target
+		s.Scanner.Log(fmt.Sprintf("Resuming %s from candidate %d", s.Target, checkpoint.Offset))
+		s.Scanner.UpdateProgress(checkpoint.Offset)
+	} else {
+		for _, variant := range s.Scanner.Variants() {
+			for _, path := range s.Paths {
+				probe := ProbeKey{Variant: variant, Path: path}
+				s.Baselines[probe] = s.learnBaseline(requestCtx, probe)
+			}
+		}
 
-		if s.Results != nil {
-			close(s.Results)
+		checkpoint = TargetCheckpoint{}
+		if s.Scanner.checkpointer != nil && ctx.Err() == nil {
+			s.Scanner.checkpointer.SetBaselines(s.Target, s.Baselines, s.HTTP3Target)
 		}
-		return []SessionResult{}
 	}
 
-	s.BaselineResponse = s.learnBaseline()
-
 	var results []SessionResult
-	resultsChan := make(chan SessionResult, len(s.Scanner.Wordlist))
+	resultsChan := make(chan SessionResult, 100)
+
+	for _, result := range checkpoint.RestoredResults() {
+		results = append(results, result)
+		if s.Results != nil {
+			s.Results <- result
+		}
+	}
 
 	done := make(chan struct{})
 
@@ -103,17 +150,20 @@ func (s *Session) Scan() []SessionResult {
 		}
 	}()
 
-	concurrentLimit := s.Scanner.Options.ConcurrentVHosts
-	if concurrentLimit <= 0 {
-		concurrentLimit = 10
-	}
-	semaphore := make(chan struct{}, concurrentLimit)
+	semaphore := make(chan struct{}, s.Scanner.Options.ConcurrentVHosts)
+
+	for index, vhost := range s.candidates(ctx) {
+		if index < checkpoint.Offset {
+			continue
+		}
+
+		if !acquire(ctx, semaphore) {
+			break
+		}
 
-	for _, vhost := range s.Scanner.Wordlist {
 		s.WaitGroup.Add(1)
-		semaphore <- struct{}{}
 
-		go func(vhost string) {
+		go func(index int, vhost string) {
 			defer s.WaitGroup.Done()
 			defer func() {
 				<-semaphore
@@ -122,30 +172,39 @@ func (s *Session) Scan() []SessionResult {
 				completedCount++
 				s.Scanner.UpdateProgress(1)
 				countMutex.Unlock()
+
+				if s.Scanner.checkpointer != nil && requestCtx.Err() == nil {
+					s.Scanner.checkpointer.MarkDone(s.Target, index)
+				}
 			}()
 
-			fullResponse, err := s.Scanner.requester.RequestVHost(s.Target, vhost)
-			if err != nil {
-				return
-			}
+			for _, variant := range s.Scanner.Variants() {
+				probe, fullResponse, found := s.probeVHost(requestCtx, variant, vhost)
+				if !found {
+					continue
+				}
 
-			if s.isDifferent(*fullResponse) {
-				isAccessible := s.Scanner.isVHostDirectlyAccessible(vhost)
+				isAccessible := s.Scanner.isVHostDirectlyAccessible(requestCtx, vhost)
 
 				result := SessionResult{
-					VHost: vhost,
-					Response: &SlimResponse{
-						StatusCode:    fullResponse.StatusCode,
-						Title:         fullResponse.Title,
-						ContentLength: fullResponse.ContentLength,
-					},
+					VHost:        vhost,
+					Path:         probe.Path,
+					Response:     NewSlimResponse(fullResponse),
 					IsVHost:      true,
 					IsAccessible: isAccessible,
 				}
 
+				if s.Scanner.checkpointer != nil {
+					s.Scanner.checkpointer.AddHit(s.Target, index, NewVHostResult(result))
+				}
+
+				if s.Scanner.harWriter != nil {
+					s.Scanner.harWriter.Add(s.Target, result, fullResponse)
+				}
+
 				resultsChan <- result
 			}
-		}(vhost)
+		}(index, vhost)
 	}
 
 	s.WaitGroup.Wait()
@@ -153,6 +212,10 @@ func (s *Session) Scan() []SessionResult {
 
 	<-done
 
+	if s.Scanner.checkpointer != nil && ctx.Err() == nil {
+		s.Scanner.checkpointer.CompleteTarget(s.Target)
+	}
+
 	if s.Scanner.Options.Verbose && len(results) > 0 {
 		s.Scanner.Log(fmt.Sprintf("Found %d vhosts for %s", len(results), s.Target))
 	}
@@ -160,7 +223,62 @@ func (s *Session) Scan() []SessionResult {
 	return results
 }
 
-func (s *Session) learnBaseline() BaselineResponse {
+func (s *Session) candidates(ctx context.Context) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		index := 0
+		for ; ; index++ {
+			vhost, ok := s.Scanner.Wordlist.Next(ctx, index)
+			if !ok {
+				break
+			}
+			if !yield(index, vhost) {
+				return
+			}
+		}
+
+		for _, seed := range s.Seeds {
+			if ctx.Err() != nil {
+				return
+			}
+			if s.Scanner.Wordlist.Contains(seed) {
+				continue
+			}
+			if !yield(index, seed) {
+				return
+			}
+			index++
+		}
+	}
+}
+
+func (s *Session) probeVHost(ctx context.Context, variant string, vhost string) (ProbeKey, *FullResponse, bool) {
+	for _, path := range s.Paths {
+		probe := ProbeKey{Variant: variant, Path: path}
+
+		fullResponse, err := s.requestVHost(ctx, probe, vhost)
+		if err != nil {
+			continue
+		}
+
+		hit := s.isHit(s.Baselines[probe], vhost, path, *fullResponse)
+		if s.Scanner.ipMatrix != nil && variant == s.Scanner.Variants()[0] && path == s.Paths[0] {
+			s.Scanner.ipMatrix.Add(s.Target, vhost, fullResponse, hit)
+		}
+
+		if hit && s.Scanner.shouldReport(fullResponse) {
+			return probe, fullResponse, true
+		}
+	}
+
+	return ProbeKey{}, nil, false
+}
+
+func (s *Session) learnBaseline(ctx context.Context, probe ProbeKey) BaselineResponse {
+	baseline, _ := s.sampleBaseline(ctx, probe)
+	return baseline
+}
+
+func (s *Session) sampleBaseline(ctx context.Context, probe ProbeKey) (BaselineResponse, []BaselineSample) {
 	targetHost := GetHostFromURL(s.Target)
 
 	randomVHosts := []string{
@@ -173,9 +291,14 @@ func (s *Session) learnBaseline() BaselineResponse {
 	var statusCodes []int
 	var titles []string
 	var bodies []string
+	var samples []BaselineSample
 
 	for _, vhost := range randomVHosts {
-		resp, err := s.Scanner.requester.RequestVHost(s.Target, vhost)
+		baselineProbe := s.buildProbe(probe, vhost)
+		baselineProbe.Baseline = true
+
+		resp, err := s.Scanner.requester.Probe(ctx, baselineProbe)
+		samples = append(samples, NewBaselineSample(vhost, resp, err))
 		if err != nil {
 			continue
 		}
@@ -194,30 +317,30 @@ func (s *Session) learnBaseline() BaselineResponse {
 	}
 
 	if len(randomResults) == 0 {
-		return BaselineResponse{}
+		return BaselineResponse{}, samples
 	}
 
 	return BaselineResponse{
 		StatusCodes: statusCodes,
 		Titles:      titles,
 		Bodies:      bodies,
-	}
+	}, samples
 }
 
-func (s *Session) isDifferent(response FullResponse) bool {
-	if s.BaselineResponse.StatusCodes == nil {
+func (s *Session) isDifferent(baseline BaselineResponse, response FullResponse) bool {
+	if baseline.StatusCodes == nil {
 		return false
 	}
 
-	if len(s.BaselineResponse.StatusCodes) == 0 {
+	if len(baseline.StatusCodes) == 0 {
 		return true
 	}
 
-	if !slices.Contains(s.BaselineResponse.StatusCodes, response.StatusCode) {
+	if !slices.Contains(baseline.StatusCodes, response.StatusCode) {
 		return true
 	}
 
-	if response.Title != "" && !slices.Contains(s.BaselineResponse.Titles, response.Title) {
+	if response.Title != "" && !slices.Contains(baseline.Titles, response.Title) {
 		return true
 	}
 
@@ -226,9 +349,9 @@ func (s *Session) isDifferent(response FullResponse) bool {
 	}
 
 	isSignificantlyDifferent := true
-	for _, baselineBody := range s.BaselineResponse.Bodies {
+	for _, baselineBody := range baseline.Bodies {
 		similarity := CalculateSimilarity(response.Body, baselineBody)
-		if similarity > 40 {
+		if similarity > s.Scanner.Options.SimilarityThreshold {
 			isSignificantlyDifferent = false
 			break
 		}
@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type StreamList struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	items  []string
+	index  map[string]struct{}
+	closed bool
+}
+
+func NewStreamList() *StreamList {
+	list := &StreamList{index: make(map[string]struct{})}
+	list.cond = sync.NewCond(&list.mutex)
+	return list
+}
+
+func NewStaticList(items []string) *StreamList {
+	list := NewStreamList()
+	list.items = slices.Clone(items)
+	for _, item := range items {
+		list.index[item] = struct{}{}
+	}
+	list.closed = true
+	return list
+}
+
+func NewStreamListFromReader(reader io.Reader, expand func(string) ([]string, error), onError func(error)) *StreamList {
+	list := NewStreamList()
+
+	go func() {
+		defer list.Close()
+
+		lines := bufio.NewScanner(reader)
+		for lines.Scan() {
+			line := strings.TrimSpace(lines.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			if expand == nil {
+				list.Append(line)
+				continue
+			}
+
+			items, err := expand(line)
+			if err != nil {
+				if onError != nil {
+					onError(fmt.Errorf("skipping %q: %w", line, err))
+				}
+				continue
+			}
+			for _, item := range items {
+				list.AppendUnique(item)
+			}
+		}
+
+		if err := lines.Err(); err != nil && onError != nil {
+			onError(fmt.Errorf("error reading input: %w", err))
+		}
+	}()
+
+	return list
+}
+
+func (l *StreamList) Append(item string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.items = append(l.items, item)
+	l.index[item] = struct{}{}
+	l.cond.Broadcast()
+}
+
+func (l *StreamList) AppendUnique(item string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.index[item]; ok {
+		return
+	}
+
+	l.items = append(l.items, item)
+	l.index[item] = struct{}{}
+	l.cond.Broadcast()
+}
+
+func (l *StreamList) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.closed = true
+	l.cond.Broadcast()
+}
+
+func (l *StreamList) Get(index int) (string, bool) {
+	return l.Next(context.Background(), index)
+}
+
+func (l *StreamList) Next(ctx context.Context, index int) (string, bool) {
+	stop := context.AfterFunc(ctx, func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.cond.Broadcast()
+	})
+	defer stop()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for index >= len(l.items) && !l.closed && ctx.Err() == nil {
+		l.cond.Wait()
+	}
+
+	if ctx.Err() != nil || index >= len(l.items) {
+		return "", false
+	}
+	return l.items[index], true
+}
+
+func (l *StreamList) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.items)
+}
+
+func (l *StreamList) Closed() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.closed
+}
+
+func (l *StreamList) Contains(item string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, ok := l.index[item]
+	return ok
+}
+
+func (l *StreamList) All() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for !l.closed {
+		l.cond.Wait()
+	}
+
+	return slices.Clone(l.items)
+}
@@ -0,0 +1,225 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const maxCIDRAddresses = 65536
+
+var DefaultPorts = []int{80, 443}
+
+func ParsePorts(value string) ([]int, error) {
+	var ports []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(part, "-")
+		if !isRange {
+			end = start
+		}
+
+		first, err := parsePort(start)
+		if err != nil {
+			return nil, err
+		}
+		last, err := parsePort(end)
+		if err != nil {
+			return nil, err
+		}
+		if first > last {
+			return nil, fmt.Errorf("invalid port range %q", part)
+		}
+
+		for port := first; port <= last; port++ {
+			ports = append(ports, port)
+		}
+	}
+
+	return ports, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return port, nil
+}
+
+type TargetPaths struct {
+	mutex sync.RWMutex
+	paths map[string][]string
+}
+
+func NewTargetPaths() *TargetPaths {
+	return &TargetPaths{paths: make(map[string][]string)}
+}
+
+func SplitTargetPaths(input string) (string, []string) {
+	target, list, found := strings.Cut(input, "|")
+	if !found {
+		return input, nil
+	}
+
+	var paths []string
+	for _, path := range strings.Split(list, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return strings.TrimSpace(target), paths
+}
+
+func (t *TargetPaths) Expand(input string, ports []int) ([]string, error) {
+	input, paths := SplitTargetPaths(input)
+
+	targets, err := ExpandTarget(input, ports)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, target := range targets {
+		t.Add(target, paths)
+	}
+	return targets, nil
+}
+
+func (t *TargetPaths) Add(target string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, path := range paths {
+		if !slices.Contains(t.paths[target], path) {
+			t.paths[target] = append(t.paths[target], path)
+		}
+	}
+}
+
+func (t *TargetPaths) For(target string) []string {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return slices.Clone(t.paths[target])
+}
+
+func ExpandTargets(inputs []string, ports []int, targetPaths *TargetPaths, onError func(error)) []string {
+	if len(ports) == 0 {
+		ports = DefaultPorts
+	}
+
+	var targets []string
+	seen := make(map[string]bool)
+	add := func(target string) {
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	for _, input := range inputs {
+		input = strings.TrimSpace(input)
+		if input == "" || strings.HasPrefix(input, "#") {
+			continue
+		}
+
+		expanded, err := targetPaths.Expand(input, ports)
+		if err != nil {
+			if onError != nil {
+				onError(fmt.Errorf("skipping %q: %w", input, err))
+			}
+			continue
+		}
+
+		for _, target := range expanded {
+			add(target)
+		}
+	}
+
+	return targets
+}
+
+func ExpandTarget(input string, ports []int) ([]string, error) {
+	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		return []string{input}, nil
+	}
+
+	if strings.Contains(input, "/") {
+		prefix, err := netip.ParsePrefix(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q: %w", input, err)
+		}
+		return expandCIDR(prefix.Masked(), ports)
+	}
+
+	if host, port, err := net.SplitHostPort(input); err == nil {
+		portNumber, err := parsePort(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q: %w", input, err)
+		}
+		return expandHost(host, []int{portNumber}), nil
+	}
+
+	return expandHost(strings.Trim(input, "[]"), ports), nil
+}
+
+func expandCIDR(prefix netip.Prefix, ports []int) ([]string, error) {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > 16 {
+		return nil, fmt.Errorf("CIDR range %s is larger than %d addresses", prefix, maxCIDRAddresses)
+	}
+
+	var targets []string
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		targets = append(targets, expandHost(addr.String(), ports)...)
+	}
+
+	return targets, nil
+}
+
+func expandHost(host string, ports []int) []string {
+	var targets []string
+	for _, port := range ports {
+		for _, scheme := range portSchemes(port) {
+			targets = append(targets, FormatTargetURL(scheme, host, port))
+		}
+	}
+	return targets
+}
+
+func portSchemes(port int) []string {
+	switch port {
+	case 80, 8080:
+		return []string{"http"}
+	case 443, 8443:
+		return []string{"https"}
+	}
+	return []string{"http", "https"}
+}
+
+func FormatTargetURL(scheme string, host string, port int) string {
+	if (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
+		if strings.Contains(host, ":") {
+			return fmt.Sprintf("%s://[%s]", scheme, host)
+		}
+		return fmt.Sprintf("%s://%s", scheme, host)
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+		err   string
+	}{
+		{"80,443", []int{80, 443}, ""},
+		{" 8080 , 8443 ", []int{8080, 8443}, ""},
+		{"8000-8003", []int{8000, 8001, 8002, 8003}, ""},
+		{"443,", []int{443}, ""},
+		{"", nil, ""},
+		{"9000-8000", nil, `invalid port range "9000-8000"`},
+		{"80-", nil, `invalid port ""`},
+		{"0", nil, `invalid port "0"`},
+		{"65536", nil, `invalid port "65536"`},
+		{"http", nil, `invalid port "http"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			ports, err := ParsePorts(test.value)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("ParsePorts(%q) error = %v, want %q", test.value, err, test.err)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(ports, test.want) {
+				t.Errorf("ParsePorts(%q) = %v, %v; want %v", test.value, ports, err, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandTarget(t *testing.T) {
+	tests := []struct {
+		input string
+		ports []int
+		want  []string
+		err   string
+	}{
+		{"https://example.com/admin", DefaultPorts, []string{"https://example.com/admin"}, ""},
+		{"example.com", DefaultPorts, []string{"http://example.com", "https://example.com"}, ""},
+		{"example.com", []int{8080, 8443}, []string{"http://example.com:8080", "https://example.com:8443"}, ""},
+		{"example.com", []int{9000}, []string{"http://example.com:9000", "https://example.com:9000"}, ""},
+		{"10.0.0.1:443", DefaultPorts, []string{"https://10.0.0.1"}, ""},
+		{"10.0.0.1:8080", DefaultPorts, []string{"http://10.0.0.1:8080"}, ""},
+		{"10.0.0.1:3000", DefaultPorts, []string{"http://10.0.0.1:3000", "https://10.0.0.1:3000"}, ""},
+		{"10.0.0.1:0", DefaultPorts, nil, `invalid target "10.0.0.1:0": invalid port "0"`},
+		{"10.0.0.7/32", DefaultPorts, []string{"http://10.0.0.7", "https://10.0.0.7"}, ""},
+		{"10.0.0.5/30", []int{443}, []string{"https://10.0.0.4", "https://10.0.0.5", "https://10.0.0.6", "https://10.0.0.7"}, ""},
+		{"10.0.0.0/15", DefaultPorts, nil, "CIDR range 10.0.0.0/15 is larger than 65536 addresses"},
+		{"10.0.0.0/33", DefaultPorts, nil, `invalid target "10.0.0.0/33"`},
+		{"::1", DefaultPorts, []string{"http://[::1]", "https://[::1]"}, ""},
+		{"[::1]", []int{8443}, []string{"https://[::1]:8443"}, ""},
+		{"[2001:db8::1]:8080", DefaultPorts, []string{"http://[2001:db8::1]:8080"}, ""},
+		{"2001:db8::/127", []int{80}, []string{"http://[2001:db8::]", "http://[2001:db8::1]"}, ""},
+		{"2001:db8::/64", DefaultPorts, nil, "CIDR range 2001:db8::/64 is larger than 65536 addresses"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			targets, err := ExpandTarget(test.input, test.ports)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("ExpandTarget(%q) error = %v, want %q", test.input, err, test.err)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(targets, test.want) {
+				t.Errorf("ExpandTarget(%q, %v) = %v, %v; want %v", test.input, test.ports, targets, err, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandTargetsWithPaths(t *testing.T) {
+	var skipped []string
+	targetPaths := NewTargetPaths()
+
+	targets := ExpandTargets([]string{
+		"# comment",
+		"example.com|/admin, /api",
+		"https://example.com",
+		"10.0.0.1:99999",
+		"",
+	}, nil, targetPaths, func(err error) {
+		skipped = append(skipped, err.Error())
+	})
+
+	if want := []string{"http://example.com", "https://example.com"}; !slices.Equal(targets, want) {
+		t.Errorf("targets = %v, want %v", targets, want)
+	}
+	if want := []string{"/admin", "/api"}; !slices.Equal(targetPaths.For("https://example.com"), want) {
+		t.Errorf("paths of https://example.com = %v, want %v", targetPaths.For("https://example.com"), want)
+	}
+	if len(skipped) != 1 || !strings.HasPrefix(skipped[0], `skipping "10.0.0.1:99999"`) {
+		t.Errorf("skipped = %v, want one error for 10.0.0.1:99999", skipped)
+	}
+}
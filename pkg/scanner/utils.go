@@ -2,17 +2,47 @@ package scanner
 
 import (
 	"bufio"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"math/big"
+	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/adrg/strutil"
 	"github.com/adrg/strutil/metrics"
 )
 
+func drainContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(timeout, cancel)
+	})
+
+	return drainCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func acquire(ctx context.Context, semaphore chan struct{}) bool {
+	select {
+	case semaphore <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 func NormalizeURL(target string) string {
 	u, err := url.Parse(target)
 	if err != nil {
@@ -68,6 +98,91 @@ func GetHostFromURL(targetURL string) string {
 	return parsedURL.Hostname()
 }
 
+func GetPortFromURL(parsedURL *url.URL) string {
+	if port := parsedURL.Port(); port != "" {
+		return port
+	}
+
+	if parsedURL.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+func GetProbePaths(targetURL string, paths []string) []string {
+	targetPath := "/"
+	if parsedURL, err := url.Parse(targetURL); err == nil && parsedURL.RequestURI() != "" {
+		targetPath = parsedURL.RequestURI()
+	}
+
+	probePaths := []string{targetPath}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		if !slices.Contains(probePaths, path) {
+			probePaths = append(probePaths, path)
+		}
+	}
+
+	return probePaths
+}
+
+func SetURLPath(targetURL string, path string) string {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return targetURL
+	}
+
+	pathURL, err := url.Parse(path)
+	if err != nil {
+		return targetURL
+	}
+
+	parsedURL.Path = pathURL.Path
+	parsedURL.RawPath = pathURL.RawPath
+	parsedURL.RawQuery = pathURL.RawQuery
+	return parsedURL.String()
+}
+
+var (
+	digitsPattern     = regexp.MustCompile(`[0-9]+`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+func ResponseFingerprint(statusCode int, title string, body string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%s", statusCode, title, normalizeBody(body))))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+func normalizeBody(body string) string {
+	normalized := strings.ToLower(body)
+	normalized = digitsPattern.ReplaceAllString(normalized, "0")
+	return whitespacePattern.ReplaceAllString(normalized, " ")
+}
+
+func BodyHash(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
+
+func BodyFingerprint(body string) string {
+	hash := sha256.Sum256([]byte(normalizeBody(body)))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+func CountWords(body string) int {
+	return len(strings.Split(body, " "))
+}
+
+func CountLines(body string) int {
+	return len(strings.Split(body, "\n"))
+}
+
 func ExtractTitle(body string) string {
 	titleStart := strings.Index(strings.ToLower(body), "<title>")
 	if titleStart == -1 {
@@ -82,3 +197,65 @@ func ExtractTitle(body string) string {
 
 	return strings.TrimSpace(body[titleStart : titleStart+titleEnd])
 }
+
+func GetHTTP3TargetFromAltSvc(targetURL string, altSvc string) (string, bool) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return "", false
+	}
+
+	for _, entry := range strings.Split(altSvc, ",") {
+		params := strings.Split(entry, ";")
+		protocol, authority, found := strings.Cut(strings.TrimSpace(params[0]), "=")
+		if !found || protocol != "h3" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(strings.Trim(authority, `"`))
+		if err != nil || port == "" {
+			continue
+		}
+
+		if host == "" {
+			host = parsedURL.Hostname()
+		}
+
+		parsedURL.Host = net.JoinHostPort(host, port)
+		return parsedURL.String(), true
+	}
+
+	return "", false
+}
+
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(0644); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := write(tmpFile); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	return nil
+}
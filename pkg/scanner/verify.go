@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	VerifyConfirmed = "confirmed"
+	VerifyChanged   = "changed"
+	VerifyGone      = "gone"
+)
+
+type VerifyResult struct {
+	HitRecord
+	Status  string        `json:"status"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Current *VHostResult  `json:"current,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func HitVariants(hits []HitRecord) ([]string, []string) {
+	var rawVariants []string
+	var overrideModes []string
+
+	for _, hit := range hits {
+		switch {
+		case hit.Variant == "" || hit.Variant == VariantHost:
+		case slices.Contains(OverrideModes, hit.Variant):
+			if !slices.Contains(overrideModes, hit.Variant) {
+				overrideModes = append(overrideModes, hit.Variant)
+			}
+		default:
+			if !slices.Contains(rawVariants, hit.Variant) {
+				rawVariants = append(rawVariants, hit.Variant)
+			}
+		}
+	}
+
+	return rawVariants, overrideModes
+}
+
+func HitsUseHTTP3(hits []HitRecord) bool {
+	return slices.ContainsFunc(hits, func(hit HitRecord) bool {
+		return strings.HasPrefix(hit.Protocol, "HTTP/3")
+	})
+}
+
+func (s *Scanner) Verify(ctx context.Context, hits []HitRecord) []VerifyResult {
+	var targets []string
+	byTarget := make(map[string][]int)
+	for index, hit := range hits {
+		if _, ok := byTarget[hit.Target]; !ok {
+			targets = append(targets, hit.Target)
+		}
+		byTarget[hit.Target] = append(byTarget[hit.Target], index)
+	}
+
+	results := make([]VerifyResult, len(hits))
+	s.startPhase(PhaseVerify, len(hits))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, s.Options.Threads)
+
+	for _, target := range targets {
+		if !acquire(ctx, semaphore) {
+			break
+		}
+
+		wg.Add(1)
+		go func(target string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			s.verifyTarget(ctx, target, hits, byTarget[target], results)
+		}(target)
+	}
+
+	wg.Wait()
+
+	for index, result := range results {
+		if result.Status == "" {
+			results[index] = VerifyResult{HitRecord: hits[index], Status: VerifyGone, Error: "verification interrupted"}
+		}
+	}
+
+	return results
+}
+
+func (s *Scanner) verifyTarget(ctx context.Context, target string, hits []HitRecord, indexes []int, results []VerifyResult) {
+	requestCtx, cancel := drainContext(ctx, s.Options.DrainTimeout)
+	defer cancel()
+
+	headers, err := s.requester.AliveCheck(requestCtx, target)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+
+		for _, index := range indexes {
+			results[index] = VerifyResult{HitRecord: hits[index], Status: VerifyGone, Error: fmt.Sprintf("target is not alive: %v", err)}
+		}
+		s.UpdateProgress(len(indexes))
+		return
+	}
+
+	session := NewSession(s, target)
+	if s.Options.HTTP3 {
+		session.detectHTTP3(requestCtx, headers.Get("Alt-Svc"))
+	}
+
+	for _, index := range indexes {
+		if ctx.Err() != nil {
+			return
+		}
+
+		results[index] = session.verifyHit(requestCtx, hits[index])
+		s.UpdateProgress(1)
+	}
+}
+
+func (s *Session) verifyHit(ctx context.Context, hit HitRecord) VerifyResult {
+	result := VerifyResult{HitRecord: hit, Status: VerifyGone}
+
+	probe := ProbeKey{Variant: hit.Variant, Path: hit.Path}
+	if probe.Variant == "" {
+		probe.Variant = VariantHost
+	}
+	if probe.Path == "" {
+		probe.Path = s.Paths[0]
+	}
+
+	baseline, ok := s.Baselines[probe]
+	if !ok {
+		baseline = s.learnBaseline(ctx, probe)
+		s.Baselines[probe] = baseline
+	}
+
+	response, err := s.requestVHost(ctx, probe, hit.VHost)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if !s.isHit(baseline, hit.VHost, probe.Path, *response) || !s.Scanner.shouldReport(response) {
+		return result
+	}
+
+	current := NewVHostResult(SessionResult{
+		VHost:        hit.VHost,
+		Path:         probe.Path,
+		Response:     NewSlimResponse(response),
+		IsVHost:      true,
+		IsAccessible: s.Scanner.isVHostDirectlyAccessible(ctx, hit.VHost),
+	})
+	result.Current = &current
+	result.Changes = compareVHostResults(hit.VHostResult, current)
+
+	result.Status = VerifyConfirmed
+	if len(result.Changes) > 0 {
+		result.Status = VerifyChanged
+	}
+
+	return result
+}
@@ -0,0 +1,462 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var OutputFormats = []string{"json", "jsonl", "csv", "md", "html"}
+
+type Output struct {
+	Format string
+	Path   string
+}
+
+type HitRecord struct {
+	Target string `json:"target"`
+	VHostResult
+}
+
+func ParseOutputs(value string) ([]Output, error) {
+	var outputs []Output
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		output, err := ParseOutput(entry)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+func ParseOutput(value string) (Output, error) {
+	if format, path, ok := strings.Cut(value, ":"); ok && slices.Contains(OutputFormats, format) {
+		return Output{Format: format, Path: path}, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(value)) {
+	case ".jsonl", ".ndjson":
+		return Output{Format: "jsonl", Path: value}, nil
+	case ".csv":
+		return Output{Format: "csv", Path: value}, nil
+	case ".md", ".markdown":
+		return Output{Format: "md", Path: value}, nil
+	case ".html", ".htm":
+		return Output{Format: "html", Path: value}, nil
+	case ".json", "":
+		return Output{Format: "json", Path: value}, nil
+	}
+
+	return Output{}, fmt.Errorf("cannot infer output format of %q, prefix it with one of %s (e.g. csv:%s)",
+		value, strings.Join(OutputFormats, ", "), value)
+}
+
+type WriterOptions struct {
+	RotateSize int64
+	Append     bool
+}
+
+func NewResultWriter(output Output, options WriterOptions) (ResultWriter, error) {
+	switch output.Format {
+	case "json":
+		if options.RotateSize > 0 {
+			return nil, fmt.Errorf("cannot rotate json output %s, it is a snapshot of every hit kept in memory; use jsonl to rotate", output.Path)
+		}
+		return NewOutputWriter(output.Path, options.Append)
+	case "jsonl":
+		return NewJSONLWriter(output.Path, options)
+	case "csv":
+		return NewCSVWriter(output.Path, options)
+	case "md":
+		return NewMarkdownWriter(output.Path, options)
+	case "html":
+		if options.Append {
+			return nil, fmt.Errorf("cannot append to html output %s, it is a snapshot of the current scan", output.Path)
+		}
+		if options.RotateSize > 0 {
+			return nil, fmt.Errorf("cannot rotate html output %s, it is a snapshot of the current scan", output.Path)
+		}
+		return NewHTMLWriter(output.Path)
+	}
+
+	return nil, fmt.Errorf("unknown output format %q (available: %s)", output.Format, strings.Join(OutputFormats, ", "))
+}
+
+type rotatingFile struct {
+	path    string
+	header  []byte
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+func createRotatingFile(path string, header []byte, options WriterOptions) (*rotatingFile, error) {
+	file := &rotatingFile{path: path, header: header, maxSize: options.RotateSize}
+	if err := file.open(options.Append); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (f *rotatingFile) open(appendMode bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if err := f.checkHeader(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(f.path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if f.size > 0 {
+		return nil
+	}
+
+	if _, err := file.Write(f.header); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write output header: %w", err)
+	}
+	f.size = int64(len(f.header))
+	return nil
+}
+
+func (f *rotatingFile) checkHeader() error {
+	if len(f.header) == 0 {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	defer file.Close()
+
+	existing := make([]byte, len(f.header))
+	n, err := io.ReadFull(file, existing)
+	if n == 0 {
+		return nil
+	}
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	if !bytes.Equal(existing[:n], f.header) {
+		return fmt.Errorf("cannot append to %s, its header does not match the current columns", f.path)
+	}
+	return nil
+}
+
+func (f *rotatingFile) Write(content []byte) (int, error) {
+	if f.maxSize > 0 && f.size > int64(len(f.header)) && f.size+int64(len(content)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(content)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	extension := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, extension) + "." + time.Now().Format("20060102T150405.000")
+	rotated := base + extension
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			break
+		}
+		rotated = fmt.Sprintf("%s-%d%s", base, i, extension)
+	}
+	if err := os.Rename(f.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate output file: %w", err)
+	}
+
+	return f.open(false)
+}
+
+func (f *rotatingFile) Sync() error {
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) Close() error {
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("failed to sync output file: %w", err)
+	}
+	return f.file.Close()
+}
+
+type JSONLWriter struct {
+	mutex sync.Mutex
+	file  *rotatingFile
+}
+
+func NewJSONLWriter(path string, options WriterOptions) (*JSONLWriter, error) {
+	file, err := createRotatingFile(path, nil, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLWriter{file: file}, nil
+}
+
+func (w *JSONLWriter) WriteHit(target string, result SessionResult) error {
+	if !result.IsVHost {
+		return nil
+	}
+
+	return w.writeLine(HitRecord{Target: target, VHostResult: NewVHostResult(result)})
+}
+
+func (w *JSONLWriter) WriteResults(target string, results []SessionResult) error {
+	return nil
+}
+
+func (w *JSONLWriter) WriteOriginResult(result OriginResult) error {
+	return w.writeLine(NewOriginRecord(result))
+}
+
+func (w *JSONLWriter) writeLine(value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, err := w.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+func (w *JSONLWriter) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Sync()
+}
+
+func (w *JSONLWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Close()
+}
+
+var csvHeader = []string{
+	"target", "vhost", "path", "status_code", "title", "content_length",
+	"is_accessible", "protocol", "variant", "fingerprint", "response_time_ms", "server",
+	"technologies", "content_type", "location", "body_hash", "body_fingerprint", "ip",
+	"tls_subject", "tls_issuer", "words", "lines",
+}
+
+type CSVWriter struct {
+	mutex sync.Mutex
+	file  *rotatingFile
+}
+
+func NewCSVWriter(path string, options WriterOptions) (*CSVWriter, error) {
+	header, err := csvRow(csvHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := createRotatingFile(path, header, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CSVWriter{file: file}, nil
+}
+
+func (w *CSVWriter) WriteHit(target string, result SessionResult) error {
+	if !result.IsVHost {
+		return nil
+	}
+
+	hit := NewVHostResult(result)
+	row, err := csvRow([]string{
+		target,
+		hit.VHost,
+		hit.Path,
+		strconv.Itoa(hit.StatusCode),
+		hit.Title,
+		strconv.FormatInt(hit.ContentLength, 10),
+		strconv.FormatBool(hit.IsAccessible),
+		hit.Protocol,
+		hit.Variant,
+		hit.Fingerprint,
+		strconv.FormatInt(hit.ResponseTime, 10),
+		hit.Server,
+		strings.Join(hit.Technologies, "; "),
+		hit.ContentType,
+		hit.Location,
+		hit.BodyHash,
+		hit.BodyFingerprint,
+		hit.IP,
+		hit.TLSSubject,
+		hit.TLSIssuer,
+		strconv.Itoa(hit.Words),
+		strconv.Itoa(hit.Lines),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, err := w.file.Write(row); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+	return nil
+}
+
+func (w *CSVWriter) WriteResults(target string, results []SessionResult) error {
+	return nil
+}
+
+func (w *CSVWriter) WriteOriginResult(result OriginResult) error {
+	return nil
+}
+
+func (w *CSVWriter) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Sync()
+}
+
+func (w *CSVWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Close()
+}
+
+func csvRow(fields []string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write(fields)
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV row: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+const markdownHeader = "| Target | VHost | Path | Status | Title | Length | Accessible | Protocol | Variant | Fingerprint " +
+	"| Time (ms) | Server | Technologies | Content-Type | Location | Body Hash | Body Fingerprint | IP " +
+	"| TLS Subject | TLS Issuer | Words | Lines |\n" +
+	"|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|---|\n"
+
+type MarkdownWriter struct {
+	mutex sync.Mutex
+	file  *rotatingFile
+}
+
+func NewMarkdownWriter(path string, options WriterOptions) (*MarkdownWriter, error) {
+	file, err := createRotatingFile(path, []byte(markdownHeader), options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarkdownWriter{file: file}, nil
+}
+
+func (w *MarkdownWriter) WriteHit(target string, result SessionResult) error {
+	if !result.IsVHost {
+		return nil
+	}
+
+	hit := NewVHostResult(result)
+	accessible := "no"
+	if hit.IsAccessible {
+		accessible = "yes"
+	}
+
+	cells := []string{
+		target, hit.VHost, hit.Path, strconv.Itoa(hit.StatusCode), hit.Title,
+		strconv.FormatInt(hit.ContentLength, 10), accessible, hit.Protocol, hit.Variant, hit.Fingerprint,
+		strconv.FormatInt(hit.ResponseTime, 10), hit.Server, strings.Join(hit.Technologies, ", "), hit.ContentType,
+		hit.Location, hit.BodyHash, hit.BodyFingerprint, hit.IP, hit.TLSSubject, hit.TLSIssuer,
+		strconv.Itoa(hit.Words), strconv.Itoa(hit.Lines),
+	}
+	for i, cell := range cells {
+		cells[i] = escapeMarkdownCell(cell)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if _, err := w.file.Write([]byte("| " + strings.Join(cells, " | ") + " |\n")); err != nil {
+		return fmt.Errorf("failed to write Markdown row: %w", err)
+	}
+	return nil
+}
+
+func (w *MarkdownWriter) WriteResults(target string, results []SessionResult) error {
+	return nil
+}
+
+func (w *MarkdownWriter) WriteOriginResult(result OriginResult) error {
+	return nil
+}
+
+func (w *MarkdownWriter) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Sync()
+}
+
+func (w *MarkdownWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.file.Close()
+}
+
+func escapeMarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\n", " ")
+	return strings.ReplaceAll(value, "\r", "")
+}
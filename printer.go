@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+	"github.com/fatih/color"
+	"github.com/schollz/progressbar/v3"
+)
+
+type printer struct {
+	mutex       sync.Mutex
+	scanner     *scanner.Scanner
+	progressBar *progressbar.ProgressBar
+	phase       string
+	completed   int
+	origins     int
+	verbose     bool
+	silent      bool
+	noProgress  bool
+}
+
+func (p *printer) Hooks() scanner.Hooks {
+	return scanner.Hooks{
+		OnHit:          p.printResult,
+		OnProgress:     p.updateProgress,
+		OnLog:          p.println,
+		OnOriginResult: p.printOriginResult,
+		OnError: func(target string, err error) {
+			p.Warn(err)
+		},
+		OnTargetStart: func(target string) {
+			if p.verbose {
+				p.println(fmt.Sprintf("Scanning %s", target))
+			}
+		},
+	}
+}
+
+func (p *printer) Info(format string, a ...any) {
+	if p.silent {
+		return
+	}
+	p.println(fmt.Sprintf(format, a...))
+}
+
+func (p *printer) Warn(err error) {
+	p.println(fmt.Sprintf("Warning: %v", err))
+}
+
+func (p *printer) println(message string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.progressBar != nil {
+		p.progressBar.Clear()
+	}
+	fmt.Println(message)
+	if p.progressBar != nil {
+		p.progressBar.RenderBlank()
+	}
+}
+
+func (p *printer) updateProgress(progress scanner.Progress) {
+	if p.noProgress {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if progress.Phase != p.phase {
+		p.finishProgress()
+		p.phase = progress.Phase
+		p.progressBar = newProgressBar(progress)
+	}
+
+	if progress.Completed > p.completed {
+		p.completed = progress.Completed
+		p.progressBar.Set(progress.Completed)
+	}
+}
+
+func (p *printer) Finish() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.finishProgress()
+}
+
+func (p *printer) finishProgress() {
+	if p.progressBar != nil {
+		p.progressBar.Clear()
+		p.progressBar.Close()
+		p.progressBar = nil
+	}
+	p.phase = ""
+	p.completed = 0
+}
+
+func newProgressBar(progress scanner.Progress) *progressbar.ProgressBar {
+	description, unit := "Scanning virtual hosts", "vhosts"
+	clearOnFinish := true
+
+	switch progress.Phase {
+	case scanner.PhaseFilter:
+		description, unit = "Filtering wordlist", "host"
+		clearOnFinish = false
+	case scanner.PhaseOrigin:
+		description, unit = "Discovering origins", "requests"
+	case scanner.PhaseVerify:
+		description, unit = "Verifying hits", "hits"
+	}
+
+	options := []progressbar.Option{
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "=",
+			SaucerHead:    ">",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+		progressbar.OptionSetItsString(unit),
+		progressbar.OptionShowCount(),
+		progressbar.OptionSetPredictTime(true),
+		progressbar.OptionEnableColorCodes(true),
+	}
+	if clearOnFinish {
+		options = append(options, progressbar.OptionClearOnFinish())
+	}
+
+	return progressbar.NewOptions(progress.Total, options...)
+}
+
+func (p *printer) printResult(target string, result scanner.SessionResult) {
+	if !result.IsVHost {
+		return
+	}
+
+	accessibleStr := "✓"
+	if !result.IsAccessible {
+		accessibleStr = "✗"
+	}
+
+	statusColor := color.New(color.FgGreen)
+	if result.Response.StatusCode >= 400 {
+		statusColor = color.New(color.FgRed)
+	} else if result.Response.StatusCode >= 300 {
+		statusColor = color.New(color.FgYellow)
+	}
+
+	accessibleColor := color.New(color.FgGreen)
+	if !result.IsAccessible {
+		accessibleColor = color.New(color.FgRed)
+	}
+
+	resultStr := fmt.Sprintf("%s - %s [%s] [%s] [Accessible: %s]",
+		color.YellowString(target),
+		color.CyanString(result.VHost),
+		statusColor.Sprintf("%d", result.Response.StatusCode),
+		color.WhiteString(result.Response.Title),
+		accessibleColor.Sprint(accessibleStr),
+	)
+
+	options := p.scanner.Options
+	if options.HTTP3 {
+		resultStr += fmt.Sprintf(" [%s]", color.MagentaString(result.Response.Protocol))
+	}
+
+	if len(p.scanner.Variants()) > 1 || len(options.RawVariants) > 0 {
+		resultStr += fmt.Sprintf(" [Variant: %s]", color.BlueString(result.Response.Variant))
+	}
+
+	if len(p.scanner.ProbePaths(target)) > 1 {
+		resultStr += fmt.Sprintf(" [Path: %s]", color.BlueString(result.Path))
+	}
+
+	p.println(resultStr)
+}
+
+func (p *printer) printOriginResult(result scanner.OriginResult) {
+	p.mutex.Lock()
+	p.origins++
+	p.mutex.Unlock()
+
+	if result.Reference == nil {
+		p.println(fmt.Sprintf("%s - %s", color.CyanString(result.Hostname), color.RedString("live response could not be fetched")))
+		return
+	}
+
+	lines := []string{fmt.Sprintf("%s - live [%d] [%s]",
+		color.CyanString(result.Hostname),
+		result.Reference.StatusCode,
+		color.WhiteString(result.Reference.Title),
+	)}
+
+	for i, candidate := range result.Candidates {
+		scoreColor := color.New(color.FgRed)
+		if candidate.Score >= 80 {
+			scoreColor = color.New(color.FgGreen)
+		} else if candidate.Score >= 50 {
+			scoreColor = color.New(color.FgYellow)
+		}
+
+		lines = append(lines, fmt.Sprintf("  %d. %s [Score: %s] [%d] [%s] [Similarity: %.1f%%]",
+			i+1,
+			color.YellowString(candidate.Target),
+			scoreColor.Sprintf("%.1f", candidate.Score),
+			candidate.StatusCode,
+			color.WhiteString(candidate.Title),
+			candidate.Similarity,
+		))
+	}
+
+	p.println(strings.Join(lines, "\n"))
+}
+
+func (p *printer) PrintSummary(summary scanner.ScanSummary) {
+	if p.silent {
+		return
+	}
+
+	options := p.scanner.Options
+
+	if options.OriginMode {
+		if summary.Interrupted {
+			fmt.Printf("\nOrigin discovery interrupted! Partial rankings for %d hostnames\n", p.origins)
+		} else {
+			fmt.Printf("\nOrigin discovery completed!\n")
+		}
+	} else if summary.Interrupted {
+		fmt.Printf("\nScan interrupted! Found %d vhosts, %d of %d started targets completed\n",
+			summary.Hits, summary.CompletedTargets, summary.StartedTargets)
+		if options.StateFile != "" {
+			fmt.Printf("Progress saved to %s, continue with -resume %s\n", options.StateFile, options.StateFile)
+		}
+	} else {
+		fmt.Printf("\nScan completed! Found %d vhosts across %d targets\n", summary.Hits, summary.CompletedTargets)
+	}
+
+	for _, output := range options.Outputs {
+		fmt.Printf("Results saved to %s\n", output.Path)
+	}
+}
+
+func printMatrix(entries []scanner.MatrixEntry) {
+	if len(entries) == 0 {
+		fmt.Println("\nHost-to-IP matrix: no target answered any hostname")
+		return
+	}
+
+	fmt.Println("\nHost-to-IP matrix:")
+	for _, entry := range entries {
+		fmt.Println(color.CyanString(entry.Host))
+		for _, group := range entry.Groups {
+			served := color.GreenString("served")
+			if !group.Served {
+				served = color.New(color.Faint).Sprint("catch-all")
+			}
+			fmt.Printf("  [%s] [%s] [%d] [%s] %s\n",
+				served,
+				color.MagentaString(group.Fingerprint),
+				group.StatusCode,
+				color.WhiteString(group.Title),
+				color.YellowString(strings.Join(group.Targets, ", ")),
+			)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+)
+
+func runScan(arguments []string) {
+	var args scanArgs
+
+	flags := newFlagSet("scan", "[options]", "Scan targets for virtual hosts. Targets come from -u, -l, -import or -replay, candidate hostnames from -w.")
+	flags.StringVar(&args.targets, "u", "", "Comma-separated list of targets to scan; append |/path,/path to a target to probe extra paths on it only")
+	flags.StringVar(&args.targetsList, "l", "", "Path to file containing targets (one per line, optionally with |/path,/path), or - for stdin")
+	flags.StringVar(&args.wordlist, "w", "", "Path to file containing vhosts (one per line), or - for stdin")
+	flags.BoolVar(&args.internal, "internal", false, "Filter wordlist to only include internal hosts")
+	flags.StringVar(&args.outputFile, "o", "", "Comma-separated output files; the format is taken from the extension (.json, .jsonl, .csv, .md, .html) or a format: prefix (e.g. csv:hits.txt)")
+	flags.StringVar(&args.ports, "ports", "80,443", "Ports to expand bare IPs, hostnames and CIDR ranges into (e.g. 80,443,8000-8100)")
+	flags.StringVar(&args.importFile, "import", "", "Path to nmap XML, masscan JSON/list or naabu JSONL output to import targets from")
+	flags.StringVar(&args.importFormat, "import-format", "auto", "Format of the -import file (auto, nmap, masscan, naabu)")
+	flags.BoolVar(&args.ipMode, "ip-mode", false, "Treat targets as IPs and the wordlist as hostnames, and print a host-to-IP matrix grouped by response fingerprint")
+	flags.StringVar(&args.matrixFile, "matrix", "", "Path to save the -ip-mode host-to-IP matrix as JSON")
+	flags.BoolVar(&args.origin, "origin", false, "Origin discovery: send each public hostname (-w) to each candidate IP and rank IPs by similarity to the live CDN response")
+	flags.StringVar(&args.stateFile, "state", "", "Path to periodically save scan progress to, so the scan can be resumed later")
+	flags.StringVar(&args.resume, "resume", "", "Path to a state file from an interrupted scan to continue from")
+	flags.StringVar(&args.recordFile, "record", "", "Path to record every probe, alive check and accessibility check to (JSONL)")
+	flags.StringVar(&args.replayFile, "replay", "", "Path to a -record file to re-run detection against offline, without network access")
+	flags.StringVar(&args.harFile, "har", "", "Path to save the request and response of every confirmed vhost as a HAR file")
+	flags.IntVar(&args.harBodyLimit, "har-body-limit", scanner.DefaultHARBodyLimit, "Maximum number of response body bytes to store per -har entry")
+	flags.DurationVar(&args.syncInterval, "sync-interval", 5*time.Second, "How often to fsync streamed outputs and rewrite the json, html and HAR snapshots")
+	flags.Int64Var(&args.rotateSize, "rotate-size", 0, "Rotate jsonl, csv and md outputs once they exceed this many bytes (0 disables rotation, json and html cannot be rotated)")
+	flags.BoolVar(&args.appendOutput, "append", false, "Merge hits into existing json outputs, deduplicated by target, vhost, path and variant, and append to jsonl, csv and md outputs instead of overwriting them")
+	args.registerDetectionFlags(flags)
+	args.registerProbeFlags(flags)
+	args.registerRequestFlags(flags)
+	args.registerDisplayFlags(flags)
+	args.registerConfigFlags(flags)
+	parseFlags(flags, arguments, &args)
+
+	options, err := args.scannerOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cli := args.newPrinter()
+
+	var replay *scanner.ReplayRequester
+	if args.replayFile != "" {
+		replay, err = scanner.LoadReplayRequester(args.replayFile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if args.targets == "" && args.targetsList == "" && args.importFile == "" && replay == nil {
+		fmt.Println("Error: either -u, -l, -import or -replay parameter is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if args.targetsList == "-" && args.wordlist == "-" {
+		fmt.Println("Error: only one of -l and -w can be read from stdin")
+		os.Exit(1)
+	}
+
+	ports, err := scanner.ParsePorts(args.ports)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var targets []string
+	if args.targets != "" {
+		targets = splitTargets(args.targets)
+	} else if args.targetsList != "" && args.targetsList != "-" {
+		targets, err = readLines(args.targetsList)
+		if err != nil {
+			fmt.Printf("Error reading targets file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	targetPaths := scanner.NewTargetPaths()
+	targets = scanner.ExpandTargets(targets, ports, targetPaths, cli.Warn)
+
+	if replay != nil && args.targets == "" && args.targetsList == "" && args.importFile == "" {
+		targets = replay.Targets()
+	}
+
+	var seeds map[string][]string
+	if args.importFile != "" {
+		imported, err := scanner.LoadImportFile(args.importFile, args.importFormat)
+		if err != nil {
+			fmt.Printf("Error importing targets: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, target := range scanner.ImportedTargetURLs(imported) {
+			if !slices.Contains(targets, target) {
+				targets = append(targets, target)
+			}
+		}
+		seeds = scanner.ImportedSeeds(imported)
+
+		cli.Info("Imported %d web services and %d hostname seeds from %s", len(imported), countSeeds(seeds), args.importFile)
+	}
+
+	var targetList *scanner.StreamList
+	if args.targetsList == "-" && args.targets == "" {
+		targetList = scanner.NewStreamListFromReader(os.Stdin, func(line string) ([]string, error) {
+			return targetPaths.Expand(line, ports)
+		}, cli.Warn)
+		for _, target := range targets {
+			targetList.AppendUnique(target)
+		}
+	} else {
+		targetList = scanner.NewStaticList(targets)
+	}
+
+	var wordlist *scanner.StreamList
+
+	if args.wordlist == "-" {
+		wordlist = scanner.NewStreamListFromReader(os.Stdin, nil, cli.Warn)
+	} else if args.wordlist != "" {
+		hostnames, err := readLines(args.wordlist)
+		if err != nil {
+			fmt.Printf("Error reading wordlist file: %v\n", err)
+			os.Exit(1)
+		}
+		wordlist = scanner.NewStaticList(hostnames)
+	} else if replay != nil {
+		wordlist = scanner.NewStaticList(replay.VHosts())
+	} else if len(seeds) > 0 {
+		wordlist = scanner.NewStaticList(nil)
+	} else {
+		fmt.Println("Error: wordlist parameter is required")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	outputs, err := scanner.ParseOutputs(args.outputFile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if replay != nil {
+		options.Requester = replay
+	}
+
+	stateFile := args.stateFile
+	if args.resume != "" {
+		stateFile = args.resume
+	}
+
+	options.Outputs = outputs
+	options.TargetPaths = targetPaths
+	options.Seeds = seeds
+	options.IPMode = args.ipMode || args.matrixFile != ""
+	options.OriginMode = args.origin
+	options.StateFile = stateFile
+	options.Resume = args.resume != ""
+	options.RecordFile = args.recordFile
+	options.HARFile = args.harFile
+	options.HARBodyLimit = args.harBodyLimit
+	options.SyncInterval = args.syncInterval
+	options.RotateSize = args.rotateSize
+	options.AppendOutput = args.appendOutput
+	options.Hooks = cli.Hooks()
+
+	scannerInstance, err := scanner.NewStreamingScanner(targetList, wordlist, options)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := scannerInstance.Close(); err != nil {
+			cli.Warn(err)
+		}
+	}()
+	cli.scanner = scannerInstance
+
+	if args.resume != "" {
+		cli.Info("Resuming scan from %s", stateFile)
+	}
+	if replay != nil {
+		cli.Info("Replaying recorded responses from %s, no requests will be sent", args.replayFile)
+	}
+	if args.recordFile != "" {
+		cli.Info("Recording probes to %s", args.recordFile)
+	}
+	if args.harFile != "" {
+		cli.Info("Evidence for confirmed vhosts will be saved to %s", args.harFile)
+	}
+	for _, output := range outputs {
+		cli.Info("Results will be saved to %s (%s)", output.Path, output.Format)
+	}
+
+	ctx, stop := interruptContext(cli, args.drainTimeout)
+	defer stop()
+
+	if args.internal {
+		cli.Info("Filtering wordlist to only include internal hosts (not directly accessible)...")
+		if !wordlist.Closed() {
+			cli.Info("Waiting for the streamed wordlist to finish before filtering...")
+		}
+
+		originalCount, internalCount, err := scannerInstance.RemoveNonInternalHosts(ctx)
+		cli.Finish()
+		if ctx.Err() != nil {
+			cli.Info("\nFiltering interrupted, no hostnames were scanned")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		cli.Info("\nFiltered wordlist from %d to %d internal hosts (%.1f%% reduction)",
+			originalCount,
+			internalCount,
+			100.0-(float64(internalCount)/float64(originalCount)*100.0))
+	}
+
+	if args.origin {
+		cli.Info("Using origin discovery mode - every hostname is sent to every candidate IP and compared to its live response")
+	} else {
+		if args.internal {
+			cli.Info("Using internal hosts filter - only hosts that are NOT directly accessible will be checked")
+		}
+		if args.ipMode || args.matrixFile != "" {
+			cli.Info("Using IP mode - every hostname is sent to every target to build a host-to-IP matrix")
+		}
+		if scannerInstance.IsStreaming() {
+			cli.Info("Starting scan with streamed input, entries are scanned as they arrive")
+		} else {
+			cli.Info("Starting scan with %d targets and %d hostnames in wordlist (%d total vhosts)",
+				scannerInstance.Targets.Len(), scannerInstance.Wordlist.Len(), scannerInstance.TotalVHosts())
+		}
+	}
+
+	summary := scannerInstance.Scan(ctx)
+	cli.Finish()
+	cli.PrintSummary(summary)
+
+	if matrix := scannerInstance.Matrix(); matrix != nil {
+		printMatrix(matrix.Entries())
+
+		if args.matrixFile != "" {
+			if err := matrix.WriteJSON(args.matrixFile); err != nil {
+				cli.Warn(err)
+			} else {
+				cli.Info("Host-to-IP matrix saved to %s", args.matrixFile)
+			}
+		}
+	}
+}
+
+func interruptContext(cli *printer, drainTimeout time.Duration) (context.Context, func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	stopNotice := context.AfterFunc(ctx, func() {
+		stop()
+		cli.println(fmt.Sprintf("\nInterrupted, waiting up to %s for in-flight requests...", drainTimeout))
+	})
+
+	return ctx, func() {
+		stopNotice()
+		stop()
+	}
+}
+
+func countSeeds(seeds map[string][]string) int {
+	count := 0
+	for _, hostnames := range seeds {
+		count += len(hostnames)
+	}
+	return count
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+)
+
+const (
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobCancelled = "cancelled"
+)
+
+var serveDeniedFlags = []string{"rules"}
+
+type scanRequest struct {
+	Targets  []string       `json:"targets"`
+	Wordlist []string       `json:"wordlist"`
+	Options  map[string]any `json:"options"`
+}
+
+type scanJob struct {
+	mutex      sync.Mutex
+	id         string
+	status     string
+	targets    []string
+	createdAt  time.Time
+	finishedAt time.Time
+	progress   scanner.Progress
+	hits       []scanner.HitRecord
+	errors     []string
+	internal   bool
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+type jobStatus struct {
+	ID         string              `json:"id"`
+	Status     string              `json:"status"`
+	Targets    []string            `json:"targets"`
+	CreatedAt  time.Time           `json:"created_at"`
+	FinishedAt time.Time           `json:"finished_at,omitzero"`
+	Phase      string              `json:"phase"`
+	Completed  int                 `json:"completed"`
+	Total      int                 `json:"total"`
+	HitCount   int                 `json:"hit_count"`
+	Hits       []scanner.HitRecord `json:"hits,omitempty"`
+	Errors     []string            `json:"errors,omitempty"`
+}
+
+type scanServer struct {
+	ctx        context.Context
+	mutex      sync.Mutex
+	wg         sync.WaitGroup
+	jobs       map[string]*scanJob
+	order      []string
+	maxScans   int
+	keepScans  int
+	configFile string
+	profile    string
+}
+
+func runServe(arguments []string) {
+	var args scanArgs
+	var listen string
+	var maxScans int
+	var keepScans int
+	var token string
+
+	flags := newFlagSet("serve", "[options]", "Run scans through an HTTP JSON API:\n\n"+
+		"  POST   /scans       start a scan: {\"targets\": [...], \"wordlist\": [...], \"options\": {...}}\n"+
+		"  GET    /scans       list scans\n"+
+		"  GET    /scans/{id}  progress and hits of a scan\n"+
+		"  DELETE /scans/{id}  cancel a running scan, or forget a finished one\n\n"+
+		"Scan options use the config file keys (threads, override, headers, match, ...). Options that read or\n"+
+		"write files on the server are rejected. -config and -profile provide the defaults for every scan.")
+	flags.StringVar(&listen, "listen", "127.0.0.1:8008", "Address to listen on")
+	flags.IntVar(&maxScans, "max-scans", 2, "Maximum number of scans running at the same time")
+	flags.IntVar(&keepScans, "keep", 50, "Maximum number of finished scans kept in memory; the oldest are forgotten first")
+	flags.StringVar(&token, "token", os.Getenv("GO_VHOSTS_TOKEN"), "Bearer token every request must send in the Authorization header (default: $GO_VHOSTS_TOKEN, or a random token printed at startup)")
+	args.registerConfigFlags(flags)
+	flags.Parse(arguments)
+
+	if keepScans < 1 {
+		fmt.Printf("Error: -keep must be at least 1\n")
+		os.Exit(1)
+	}
+
+	if _, err := newJobFlags(&scanArgs{}, args.configFile, args.profile); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &scanServer{
+		ctx:        ctx,
+		jobs:       make(map[string]*scanJob),
+		maxScans:   maxScans,
+		keepScans:  keepScans,
+		configFile: args.configFile,
+		profile:    args.profile,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scans", server.handleCreate)
+	mux.HandleFunc("GET /scans", server.handleList)
+	mux.HandleFunc("GET /scans/{id}", server.handleGet)
+	mux.HandleFunc("DELETE /scans/{id}", server.handleDelete)
+
+	generated := token == ""
+	if generated {
+		token = rand.Text()
+	}
+
+	httpServer := &http.Server{
+		Addr:              listen,
+		Handler:           requireHost(listen, requireToken(token, mux)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Listening on http://%s\n", listen)
+	if generated {
+		fmt.Printf("API token: %s\n", token)
+	}
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	server.wg.Wait()
+}
+
+func newJobFlags(args *scanArgs, configFile string, profile string) (*flag.FlagSet, error) {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&args.ports, "ports", "80,443", "")
+	flags.BoolVar(&args.internal, "internal", false, "")
+	args.registerDetectionFlags(flags)
+	args.registerProbeFlags(flags)
+	args.registerRequestFlags(flags)
+
+	if err := applyConfig(flags, configFile, profile); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func (s *scanServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be application/json"))
+		return
+	}
+
+	var request scanRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if len(request.Targets) == 0 || len(request.Wordlist) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("targets and wordlist are required"))
+		return
+	}
+
+	job, scannerInstance, err := s.newJob(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mutex.Lock()
+	running := 0
+	for _, existing := range s.jobs {
+		if existing.snapshot(false).Status == jobRunning {
+			running++
+		}
+	}
+	if running >= s.maxScans {
+		s.mutex.Unlock()
+		job.cancel()
+		scannerInstance.Close()
+		writeError(w, http.StatusTooManyRequests, fmt.Errorf("%d scans are already running", running))
+		return
+	}
+	s.jobs[job.id] = job
+	s.order = append(s.order, job.id)
+	s.wg.Add(1)
+	s.mutex.Unlock()
+
+	go s.run(job, scannerInstance)
+
+	writeJSON(w, http.StatusCreated, job.snapshot(true))
+}
+
+func (s *scanServer) newJob(request scanRequest) (*scanJob, *scanner.Scanner, error) {
+	var args scanArgs
+	flags, err := newJobFlags(&args, s.configFile, s.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := configFlagValues(request.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, flagValues := range values {
+		if flags.Lookup(name) == nil || slices.Contains(serveDeniedFlags, name) {
+			return nil, nil, fmt.Errorf("option -%s cannot be set through the API", name)
+		}
+		for _, value := range flagValues {
+			if err := flags.Set(name, value); err != nil {
+				return nil, nil, fmt.Errorf("invalid value for -%s: %w", name, err)
+			}
+		}
+	}
+
+	ports, err := scanner.ParsePorts(args.ports)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var skipped []string
+	targetPaths := scanner.NewTargetPaths()
+	targets := scanner.ExpandTargets(request.Targets, ports, targetPaths, func(err error) {
+		skipped = append(skipped, err.Error())
+	})
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("no valid targets: %s", strings.Join(skipped, "; "))
+	}
+
+	options, err := args.scannerOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	options.TargetPaths = targetPaths
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	job := &scanJob{
+		id:        scanner.GenerateRandomString(12),
+		status:    jobRunning,
+		targets:   targets,
+		createdAt: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+		internal:  args.internal,
+		errors:    skipped,
+	}
+
+	options.Hooks = scanner.Hooks{
+		OnHit: func(target string, result scanner.SessionResult) {
+			job.mutex.Lock()
+			defer job.mutex.Unlock()
+			job.hits = append(job.hits, scanner.HitRecord{Target: target, VHostResult: scanner.NewVHostResult(result)})
+		},
+		OnProgress: func(progress scanner.Progress) {
+			job.mutex.Lock()
+			defer job.mutex.Unlock()
+			if progress.Phase != job.progress.Phase || progress.Completed > job.progress.Completed {
+				job.progress = progress
+			}
+		},
+		OnError: func(target string, err error) {
+			job.addError(err)
+		},
+	}
+
+	scannerInstance, err := scanner.NewScanner(targets, request.Wordlist, options)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return job, scannerInstance, nil
+}
+
+func (s *scanServer) run(job *scanJob, scannerInstance *scanner.Scanner) {
+	defer s.wg.Done()
+	defer job.cancel()
+
+	fmt.Printf("Scan %s started: %d targets, %d hostnames\n", job.id, len(job.targets), scannerInstance.Wordlist.Len())
+
+	interrupted := false
+	if job.internal {
+		if _, _, err := scannerInstance.RemoveNonInternalHosts(job.ctx); err != nil {
+			interrupted = true
+		}
+	}
+	if !interrupted {
+		interrupted = scannerInstance.Scan(job.ctx).Interrupted
+	}
+
+	if err := scannerInstance.Close(); err != nil {
+		job.addError(err)
+	}
+
+	job.mutex.Lock()
+	job.finishedAt = time.Now()
+	job.status = jobCompleted
+	if interrupted {
+		job.status = jobCancelled
+	}
+	status, hits := job.status, len(job.hits)
+	job.mutex.Unlock()
+
+	fmt.Printf("Scan %s %s: %d hits\n", job.id, status, hits)
+
+	s.forgetFinished()
+}
+
+func (s *scanServer) forgetFinished() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var finished []string
+	for _, id := range s.order {
+		if s.jobs[id].snapshot(false).Status != jobRunning {
+			finished = append(finished, id)
+		}
+	}
+
+	for _, id := range finished[:max(len(finished)-s.keepScans, 0)] {
+		delete(s.jobs, id)
+		s.order = slices.DeleteFunc(s.order, func(existing string) bool { return existing == id })
+	}
+}
+
+func (j *scanJob) addError(err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.errors = append(j.errors, err.Error())
+}
+
+func (j *scanJob) snapshot(includeHits bool) jobStatus {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	status := jobStatus{
+		ID:         j.id,
+		Status:     j.status,
+		Targets:    j.targets,
+		CreatedAt:  j.createdAt,
+		FinishedAt: j.finishedAt,
+		Phase:      j.progress.Phase,
+		Completed:  j.progress.Completed,
+		Total:      j.progress.Total,
+		HitCount:   len(j.hits),
+		Errors:     slices.Clone(j.errors),
+	}
+	if includeHits {
+		status.Hits = slices.Clone(j.hits)
+	}
+	return status
+}
+
+func (s *scanServer) job(id string) (*scanJob, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+func (s *scanServer) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	statuses := make([]jobStatus, 0, len(s.order))
+	for _, id := range s.order {
+		statuses = append(statuses, s.jobs[id].snapshot(false))
+	}
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *scanServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("scan %s not found", r.PathValue("id")))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job.snapshot(true))
+}
+
+func (s *scanServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("scan %s not found", r.PathValue("id")))
+		return
+	}
+
+	if job.snapshot(false).Status == jobRunning {
+		job.cancel()
+		writeJSON(w, http.StatusAccepted, job.snapshot(false))
+		return
+	}
+
+	s.mutex.Lock()
+	delete(s.jobs, job.id)
+	s.order = slices.DeleteFunc(s.order, func(id string) bool { return id == job.id })
+	s.mutex.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func requireToken(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requireHost(listen string, next http.Handler) http.Handler {
+	listenHost, _, _ := net.SplitHostPort(listen)
+	if ip := net.ParseIP(listenHost); listenHost == "" || (ip != nil && ip.IsUnspecified()) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+
+		ip := net.ParseIP(host)
+		if !strings.EqualFold(host, listenHost) && !strings.EqualFold(host, "localhost") && (ip == nil || !ip.IsLoopback()) {
+			writeError(w, http.StatusMisdirectedRequest, fmt.Errorf("unexpected host %q", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
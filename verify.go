@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bebiksior/go-vhosts/pkg/scanner"
+	"github.com/fatih/color"
+)
+
+func runVerify(arguments []string) {
+	var args scanArgs
+	var jsonOutput bool
+
+	flags := newFlagSet("verify", "[options] <output file>", "Re-check every hit of a json or jsonl output file against its target. A fresh baseline is learned for\neach target and the same path and variant are probed again. Hits are reported as confirmed, changed\n(status code, title, content length or fingerprint differ) or gone.\n\nVariants are taken from the hits unless -raw or -override is given.")
+	flags.BoolVar(&jsonOutput, "json", false, "Print the results as JSON")
+	flags.StringVar(&args.outputFile, "o", "", "Path to save the results as JSON")
+	args.registerDetectionFlags(flags)
+	args.registerProbeFlags(flags)
+	args.registerRequestFlags(flags)
+	args.registerDisplayFlags(flags)
+	args.registerConfigFlags(flags)
+	parseFlags(flags, arguments, &args, "o")
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	hits, err := scanner.LoadHitRecords(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	options, err := args.scannerOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args.raw == "" && args.override == "" {
+		options.RawVariants, options.OverrideModes = scanner.HitVariants(hits)
+	}
+	if !options.HTTP3 && options.Proxy == "" && scanner.HitsUseHTTP3(hits) {
+		options.HTTP3 = true
+	}
+
+	cli := args.newPrinter()
+	if jsonOutput {
+		cli.silent, cli.noProgress = true, true
+	}
+
+	var targets []string
+	for _, hit := range hits {
+		targets = append(targets, hit.Target)
+	}
+
+	options.Hooks = cli.Hooks()
+	scannerInstance, err := scanner.NewScanner(targets, nil, options)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer scannerInstance.Close()
+	cli.scanner = scannerInstance
+
+	ctx, stop := interruptContext(cli, args.drainTimeout)
+	defer stop()
+
+	cli.Info("Verifying %d hits from %s", len(hits), flags.Arg(0))
+
+	results := scannerInstance.Verify(ctx, hits)
+	cli.Finish()
+
+	if args.outputFile != "" {
+		content, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(args.outputFile, append(content, '\n'), 0644); err != nil {
+			fmt.Printf("Error writing results: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+		return
+	}
+
+	printVerifyResults(results, args.silent)
+}
+
+func printVerifyResults(results []scanner.VerifyResult, silent bool) {
+	counts := make(map[string]int)
+
+	for _, result := range results {
+		counts[result.Status]++
+
+		switch result.Status {
+		case scanner.VerifyConfirmed:
+			fmt.Printf("%s %s - %s [%d] [%s]%s\n",
+				color.GreenString("✓"),
+				color.YellowString(result.Target),
+				color.CyanString(result.VHost),
+				result.Current.StatusCode,
+				color.WhiteString(result.Current.Title),
+				diffQualifier(result.Path, result.Variant),
+			)
+
+		case scanner.VerifyChanged:
+			fmt.Printf("%s %s - %s [%d] [%s]%s\n",
+				color.YellowString("~"),
+				color.YellowString(result.Target),
+				color.CyanString(result.VHost),
+				result.Current.StatusCode,
+				color.WhiteString(result.Current.Title),
+				diffQualifier(result.Path, result.Variant),
+			)
+			for _, field := range result.Changes {
+				fmt.Printf("    %s: %s -> %s\n", field.Field, formatDiffValue(field.Old), formatDiffValue(field.New))
+			}
+
+		default:
+			line := fmt.Sprintf("%s %s - %s [%d] [%s]%s %s",
+				color.RedString("✗"),
+				color.YellowString(result.Target),
+				color.CyanString(result.VHost),
+				result.StatusCode,
+				color.WhiteString(result.Title),
+				diffQualifier(result.Path, result.Variant),
+				color.RedString("no longer served"),
+			)
+			if result.Error != "" {
+				line += fmt.Sprintf(" (%s)", result.Error)
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if silent {
+		return
+	}
+
+	fmt.Printf("\n%d confirmed, %d changed, %d gone\n",
+		counts[scanner.VerifyConfirmed], counts[scanner.VerifyChanged], counts[scanner.VerifyGone])
+}